@@ -0,0 +1,83 @@
+// Command dumpreader replays packets captured by the relay's packet
+// dumper (internal/dump) back at a UDP target, for regression testing.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/whykusanagi/VTuberHub/internal/dump"
+)
+
+func main() {
+	dumpFile := flag.String("dump", "", "Path to a .pcap file written by the relay's packet dumper")
+	target := flag.String("target", "127.0.0.1:9000", "host:port to replay packets to")
+	realTime := flag.Bool("real-time", false, "Replay packets with their original inter-packet timing instead of as fast as possible")
+	flag.Parse()
+
+	if *dumpFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: dumpreader -dump <file.pcap> [-target host:port] [-real-time]")
+		os.Exit(2)
+	}
+
+	if err := run(*dumpFile, *target, *realTime); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dumpFile, target string, realTime bool) error {
+	f, err := os.Open(dumpFile)
+	if err != nil {
+		return fmt.Errorf("open dump file: %w", err)
+	}
+	defer f.Close()
+
+	reader, err := dump.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("read dump header: %w", err)
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		return fmt.Errorf("resolve target: %w", err)
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return fmt.Errorf("dial target: %w", err)
+	}
+	defer conn.Close()
+
+	var (
+		count    int
+		lastTime time.Time
+	)
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read record %d: %w", count+1, err)
+		}
+
+		if realTime && !lastTime.IsZero() {
+			if gap := rec.Timestamp.Sub(lastTime); gap > 0 {
+				time.Sleep(gap)
+			}
+		}
+		lastTime = rec.Timestamp
+
+		if _, err := conn.Write(rec.Payload); err != nil {
+			return fmt.Errorf("replay record %d (originally from %s): %w", count+1, rec.SrcAddr, err)
+		}
+		count++
+	}
+
+	fmt.Printf("replayed %d packets to %s\n", count, target)
+	return nil
+}
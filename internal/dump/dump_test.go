@@ -0,0 +1,140 @@
+package dump
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteRecordAndReadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeGlobalHeader(&buf); err != nil {
+		t.Fatalf("writeGlobalHeader: %v", err)
+	}
+
+	src := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 4242}
+	ts := time.Unix(1700000000, 123000)
+	if _, err := writeRecord(&buf, ts, src, []byte("hello")); err != nil {
+		t.Fatalf("writeRecord: %v", err)
+	}
+
+	reader, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	rec, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if rec.SrcAddr != src.String() {
+		t.Fatalf("expected src %q, got %q", src.String(), rec.SrcAddr)
+	}
+	if string(rec.Payload) != "hello" {
+		t.Fatalf("expected payload %q, got %q", "hello", rec.Payload)
+	}
+	if !rec.Timestamp.Equal(ts) {
+		t.Fatalf("expected timestamp %v, got %v", ts, rec.Timestamp)
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF after the only record, got %v", err)
+	}
+}
+
+func TestNewReaderRejectsBadMagic(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader(make([]byte, globalHeaderLen))); err == nil {
+		t.Fatalf("expected an error for a zeroed (non-pcap) header")
+	}
+}
+
+func TestWriterRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, Options{RotateSize: 64})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	src := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1}
+	payload := bytes.Repeat([]byte("x"), 32)
+	for i := 0; i < 10; i++ {
+		w.Write(time.Now(), src, payload)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "dump-*.pcap"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("expected more than one dump file from size-based rotation, got %d", len(files))
+	}
+}
+
+func TestWriterPrunesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, Options{RotateSize: 32, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	src := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1}
+	payload := bytes.Repeat([]byte("x"), 32)
+	for i := 0; i < 20; i++ {
+		w.Write(time.Now(), src, payload)
+		time.Sleep(time.Millisecond)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "dump-*.pcap"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(files) > 2 {
+		t.Fatalf("expected at most 2 retained dump files, got %d", len(files))
+	}
+}
+
+func TestWriteSafeDuringClose(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, Options{})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	src := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1}
+	payload := []byte("hello")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			w.Write(time.Now(), src, payload)
+		}
+	}()
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	<-done
+}
+
+func TestWriterCreatesDumpDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "dumps")
+	w, err := NewWriter(dir, Options{})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("expected dump directory to be created: %v", err)
+	}
+}
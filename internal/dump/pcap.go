@@ -0,0 +1,135 @@
+// Package dump writes received UDP packets to a rolling set of
+// Wireshark-readable capture files, and provides a reader to play them
+// back for regression testing.
+//
+// Files use the standard pcap container format with LINKTYPE_USER0: a
+// 24-byte global header followed by one record per packet. Since plain
+// pcap records have no field for the packet's source address, each
+// record's data is itself a small length-prefixed frame: a 2-byte source
+// address length, the address string, then the raw packet bytes.
+package dump
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	magicNumber   = 0xa1b2c3d4
+	versionMajor  = 2
+	versionMinor  = 4
+	linkTypeUser0 = 147
+	snapLen       = 65535
+
+	globalHeaderLen = 24
+	recordHeaderLen = 16
+)
+
+// writeGlobalHeader writes a pcap global header declaring LINKTYPE_USER0.
+func writeGlobalHeader(w io.Writer) error {
+	var hdr [globalHeaderLen]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], magicNumber)
+	binary.LittleEndian.PutUint16(hdr[4:6], versionMajor)
+	binary.LittleEndian.PutUint16(hdr[6:8], versionMinor)
+	// hdr[8:16] (thiszone, sigfigs) left at zero, as libpcap itself does.
+	binary.LittleEndian.PutUint32(hdr[16:20], snapLen)
+	binary.LittleEndian.PutUint32(hdr[20:24], linkTypeUser0)
+	_, err := w.Write(hdr[:])
+	return err
+}
+
+// writeRecord appends one packet to w: a pcap per-packet header, then our
+// own source-address-prefixed payload framing.
+func writeRecord(w io.Writer, ts time.Time, src net.Addr, payload []byte) (int, error) {
+	srcBytes := []byte(addrString(src))
+	if len(srcBytes) > 0xffff {
+		return 0, fmt.Errorf("dump: source address too long: %d bytes", len(srcBytes))
+	}
+
+	body := make([]byte, 2+len(srcBytes)+len(payload))
+	binary.LittleEndian.PutUint16(body[0:2], uint16(len(srcBytes)))
+	copy(body[2:], srcBytes)
+	copy(body[2+len(srcBytes):], payload)
+
+	var hdr [recordHeaderLen]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:12], uint32(len(body)))
+	binary.LittleEndian.PutUint32(hdr[12:16], uint32(len(body)))
+
+	if _, err := w.Write(hdr[:]); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return 0, err
+	}
+	return recordHeaderLen + len(body), nil
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+// Record is one packet read back from a dump file by Reader.
+type Record struct {
+	Timestamp time.Time
+	SrcAddr   string
+	Payload   []byte
+}
+
+// Reader reads packets back from a dump file written by Writer.
+type Reader struct {
+	r io.Reader
+}
+
+// NewReader validates and consumes r's global header, returning a Reader
+// positioned at the first record.
+func NewReader(r io.Reader) (*Reader, error) {
+	var hdr [globalHeaderLen]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("dump: read global header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(hdr[0:4]) != magicNumber {
+		return nil, fmt.Errorf("dump: not a pcap file (bad magic number)")
+	}
+	if linkType := binary.LittleEndian.Uint32(hdr[20:24]); linkType != linkTypeUser0 {
+		return nil, fmt.Errorf("dump: unexpected link type %d (want %d)", linkType, linkTypeUser0)
+	}
+	return &Reader{r: r}, nil
+}
+
+// Next returns the next record, or an io.EOF error once the stream is
+// exhausted.
+func (r *Reader) Next() (*Record, error) {
+	var hdr [recordHeaderLen]byte
+	if _, err := io.ReadFull(r.r, hdr[:]); err != nil {
+		return nil, err
+	}
+	sec := binary.LittleEndian.Uint32(hdr[0:4])
+	usec := binary.LittleEndian.Uint32(hdr[4:8])
+	inclLen := binary.LittleEndian.Uint32(hdr[8:12])
+
+	body := make([]byte, inclLen)
+	if _, err := io.ReadFull(r.r, body); err != nil {
+		return nil, fmt.Errorf("dump: read record body: %w", err)
+	}
+	if len(body) < 2 {
+		return nil, fmt.Errorf("dump: truncated record body")
+	}
+	addrLen := int(binary.LittleEndian.Uint16(body[0:2]))
+	if len(body) < 2+addrLen {
+		return nil, fmt.Errorf("dump: truncated source address")
+	}
+
+	return &Record{
+		Timestamp: time.Unix(int64(sec), int64(usec)*1000),
+		SrcAddr:   string(body[2 : 2+addrLen]),
+		Payload:   body[2+addrLen:],
+	}, nil
+}
@@ -0,0 +1,207 @@
+package dump
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options configures a Writer's rotation and retention policy.
+type Options struct {
+	// RotateSize rotates to a new file before the next write once the
+	// current one has reached this many bytes; the record that crosses the
+	// limit still goes to the current file. 0 disables size-based rotation.
+	RotateSize int64
+	// RotateInterval rotates to a new file once the current one has been
+	// open this long. 0 disables duration-based rotation.
+	RotateInterval time.Duration
+	// MaxFiles keeps at most this many dump files on disk, removing the
+	// oldest first on rotation. 0 disables retention pruning.
+	MaxFiles int
+	// QueueSize bounds the writer's internal buffer; packets submitted
+	// once it's full are dropped rather than blocking the caller.
+	// Defaults to 256 if <= 0.
+	QueueSize int
+}
+
+type packet struct {
+	ts      time.Time
+	src     net.Addr
+	payload []byte
+}
+
+// Writer dumps received packets to a rotating set of pcap-compatible
+// files from a single background goroutine fed by a buffered channel, so
+// a slow disk can never add latency to the relay's receive loop. Write
+// is safe to call concurrently with Close.
+type Writer struct {
+	dir  string
+	opts Options
+
+	ch chan packet
+	wg sync.WaitGroup
+
+	closeMu sync.RWMutex
+	closed  bool
+
+	dropped int64
+}
+
+// NewWriter creates dir if necessary and starts the background writer
+// goroutine.
+func NewWriter(dir string, opts Options) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+
+	w := &Writer{
+		dir:  dir,
+		opts: opts,
+		ch:   make(chan packet, opts.QueueSize),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Dir returns the directory this Writer was created with.
+func (w *Writer) Dir() string {
+	return w.dir
+}
+
+// Options returns the rotation and retention policy this Writer was
+// created with.
+func (w *Writer) Options() Options {
+	return w.opts
+}
+
+// Write submits a packet to be dumped. It never blocks: if the internal
+// queue is full, the packet is dropped and counted in Dropped. Write is
+// safe to call concurrently with Close; once the Writer is closed,
+// packets are silently dropped rather than sent on the closed channel.
+func (w *Writer) Write(ts time.Time, src net.Addr, payload []byte) {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		atomic.AddInt64(&w.dropped, 1)
+		return
+	}
+
+	cp := make([]byte, len(payload))
+	copy(cp, payload)
+
+	select {
+	case w.ch <- packet{ts: ts, src: src, payload: cp}:
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+	}
+}
+
+// Dropped returns the number of packets dropped because the internal
+// queue was full or the Writer was already closed.
+func (w *Writer) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// Close stops accepting new packets, waits for the background goroutine
+// to drain and write everything already queued, then closes the current
+// file. Close is safe to call concurrently with Write.
+func (w *Writer) Close() error {
+	w.closeMu.Lock()
+	w.closed = true
+	close(w.ch)
+	w.closeMu.Unlock()
+
+	w.wg.Wait()
+	return nil
+}
+
+func (w *Writer) run() {
+	defer w.wg.Done()
+
+	var (
+		file   *os.File
+		size   int64
+		opened time.Time
+	)
+	rotate := func() {
+		if file != nil {
+			file.Close()
+		}
+		path := filepath.Join(w.dir, fmt.Sprintf("dump-%s.pcap", time.Now().Format("20060102-150405.000000")))
+		f, err := os.Create(path)
+		if err != nil {
+			file = nil
+			return
+		}
+		if err := writeGlobalHeader(f); err != nil {
+			f.Close()
+			file = nil
+			return
+		}
+		file = f
+		size = globalHeaderLen
+		opened = time.Now()
+		w.prune()
+	}
+	rotate()
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	for p := range w.ch {
+		if file == nil {
+			continue
+		}
+		if w.shouldRotate(size, opened) {
+			rotate()
+			if file == nil {
+				continue
+			}
+		}
+		n, err := writeRecord(file, p.ts, p.src, p.payload)
+		if err != nil {
+			file.Close()
+			file = nil
+			continue
+		}
+		size += int64(n)
+	}
+}
+
+func (w *Writer) shouldRotate(size int64, opened time.Time) bool {
+	if w.opts.RotateSize > 0 && size >= w.opts.RotateSize {
+		return true
+	}
+	if w.opts.RotateInterval > 0 && time.Since(opened) >= w.opts.RotateInterval {
+		return true
+	}
+	return false
+}
+
+func (w *Writer) prune() {
+	if w.opts.MaxFiles <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(filepath.Join(w.dir, "dump-*.pcap"))
+	if err != nil || len(matches) <= w.opts.MaxFiles {
+		return
+	}
+	// Glob returns lexically sorted results; our timestamped names sort
+	// chronologically too.
+	sort.Strings(matches)
+	excess := len(matches) - w.opts.MaxFiles
+	for _, old := range matches[:excess] {
+		os.Remove(old)
+	}
+}
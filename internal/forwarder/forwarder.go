@@ -0,0 +1,332 @@
+// Package forwarder fans incoming packets out to a set of UDP targets, each
+// served by its own goroutine and bounded queue. This decouples a slow or
+// blocked target (e.g. a recorder writing to a full disk) from the rest of
+// the relay: the receive loop only ever does a non-blocking send into a
+// target's queue, so one laggy consumer cannot add latency to, or stall
+// delivery for, the others.
+package forwarder
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/whykusanagi/VTuberHub/internal/ifm"
+	"github.com/whykusanagi/VTuberHub/internal/logging"
+)
+
+// OverflowPolicy selects what happens when a target's queue is full and a
+// new packet arrives for it.
+type OverflowPolicy int
+
+const (
+	// DropOldest evicts the oldest queued packet to make room for the new
+	// one. This favors freshness, which is usually right for a live feed
+	// (e.g. the avatar renderer would rather skip a stale frame than fall
+	// behind in real time).
+	DropOldest OverflowPolicy = iota
+	// DropNewest discards the incoming packet and leaves the queue as-is.
+	// This favors completeness of whatever is already queued, which suits
+	// targets like a file recorder that process frames in submission order.
+	DropNewest
+)
+
+// ParseOverflowPolicy parses a policy name, defaulting to DropOldest for an
+// empty or unrecognized string.
+func ParseOverflowPolicy(s string) OverflowPolicy {
+	if s == "drop_newest" {
+		return DropNewest
+	}
+	return DropOldest
+}
+
+func (p OverflowPolicy) String() string {
+	if p == DropNewest {
+		return "drop_newest"
+	}
+	return "drop_oldest"
+}
+
+// TargetStats holds the running counters for one target's worker. All
+// fields are updated with atomic operations and safe to read concurrently.
+type TargetStats struct {
+	Forwarded       int64
+	DroppedOverflow int64
+	WriteErrors     int64
+	TotalLatencyNs  int64
+	latencySamples  int64
+}
+
+// AvgLatencyNs returns the mean time between a packet being received and
+// this target's worker writing it out, in nanoseconds.
+func (s *TargetStats) AvgLatencyNs() int64 {
+	samples := atomic.LoadInt64(&s.latencySamples)
+	if samples == 0 {
+		return 0
+	}
+	return atomic.LoadInt64(&s.TotalLatencyNs) / samples
+}
+
+// Snapshot is a point-in-time copy of a target's counters plus its current
+// queue depth, suitable for stats reporting or a metrics endpoint.
+type Snapshot struct {
+	Name            string
+	Forwarded       int64
+	DroppedOverflow int64
+	WriteErrors     int64
+	AvgLatencyNs    int64
+	QueueDepth      int
+	QueueCapacity   int
+
+	// FilterDrops and RateLimited are the target's IFM pipeline counters
+	// (blendshapes dropped by an allow/deny list, and frames skipped by a
+	// MaxFPS limit), or zero if the target has no pipeline configured.
+	FilterDrops int64
+	RateLimited int64
+}
+
+// Target describes a forwarding destination and its queue behavior.
+type Target struct {
+	Name      string
+	Addr      *net.UDPAddr
+	QueueSize int
+	Policy    OverflowPolicy
+
+	// IFM, if set, re-encodes each packet through an iFacialMocap
+	// pipeline (blendshape filtering, axis remap, smoothing, frame-rate
+	// limiting) before it reaches this target. It is only applied when
+	// Send is given a successfully parsed Frame; otherwise the target
+	// falls back to receiving the raw packet bytes unchanged.
+	IFM *ifm.Pipeline
+}
+
+type udpWriter interface {
+	WriteToUDP(b []byte, addr *net.UDPAddr) (int, error)
+}
+
+type job struct {
+	data     []byte
+	recvTime time.Time
+}
+
+type worker struct {
+	target Target
+	ch     chan job
+	stats  *TargetStats
+	conn   udpWriter
+	log    *logging.Logger
+	done   chan struct{}
+}
+
+func newWorker(conn udpWriter, log *logging.Logger, target Target) *worker {
+	return &worker{
+		target: target,
+		ch:     make(chan job, target.QueueSize),
+		stats:  &TargetStats{},
+		conn:   conn,
+		log:    log.Named("forward").With("target", target.Name),
+		done:   make(chan struct{}),
+	}
+}
+
+func (w *worker) run() {
+	for {
+		select {
+		case j, ok := <-w.ch:
+			if !ok {
+				return
+			}
+			w.deliver(j)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *worker) deliver(j job) {
+	n, err := w.conn.WriteToUDP(j.data, w.target.Addr)
+	latency := time.Since(j.recvTime).Nanoseconds()
+	atomic.AddInt64(&w.stats.TotalLatencyNs, latency)
+	atomic.AddInt64(&w.stats.latencySamples, 1)
+
+	if err != nil {
+		atomic.AddInt64(&w.stats.WriteErrors, 1)
+		w.log.Error("failed to forward", "error", err)
+		return
+	}
+	if n != len(j.data) {
+		atomic.AddInt64(&w.stats.WriteErrors, 1)
+		w.log.Error("partial write", "wrote", n, "size", len(j.data))
+		return
+	}
+	atomic.AddInt64(&w.stats.Forwarded, 1)
+}
+
+// enqueue offers j to the worker's queue, applying the target's overflow
+// policy if the queue is full. It never blocks.
+func (w *worker) enqueue(j job) {
+	select {
+	case w.ch <- j:
+		return
+	default:
+	}
+
+	if w.target.Policy == DropOldest {
+		select {
+		case <-w.ch:
+		default:
+		}
+		select {
+		case w.ch <- j:
+			atomic.AddInt64(&w.stats.DroppedOverflow, 1)
+			return
+		default:
+		}
+	}
+	atomic.AddInt64(&w.stats.DroppedOverflow, 1)
+}
+
+func (w *worker) stop() {
+	close(w.done)
+}
+
+// Manager owns one worker per target and fans packets out to them.
+type Manager struct {
+	mu      sync.RWMutex
+	conn    udpWriter
+	log     *logging.Logger
+	workers map[string]*worker
+}
+
+// NewManager creates an empty Manager that writes through conn.
+func NewManager(conn udpWriter, log *logging.Logger) *Manager {
+	return &Manager{
+		conn:    conn,
+		log:     log,
+		workers: make(map[string]*worker),
+	}
+}
+
+// AddTarget starts a worker goroutine for target. It returns an error if a
+// target with the same name is already registered.
+func (m *Manager) AddTarget(target Target) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.workers[target.Name]; exists {
+		return fmt.Errorf("forwarder: target %q already registered", target.Name)
+	}
+	if target.QueueSize <= 0 {
+		target.QueueSize = 64
+	}
+	w := newWorker(m.conn, m.log, target)
+	m.workers[target.Name] = w
+	go w.run()
+	return nil
+}
+
+// RemoveTarget stops and removes the named target's worker, if present. It
+// reports whether a worker was found.
+func (m *Manager) RemoveTarget(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workers[name]
+	if !ok {
+		return false
+	}
+	w.stop()
+	delete(m.workers, name)
+	return true
+}
+
+// Names returns the currently registered target names.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.workers))
+	for name := range m.workers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Send fans data out to every registered target's queue. frame is the
+// result of parsing data as an iFacialMocap packet, or nil if parsing
+// failed or wasn't attempted; targets with an IFM pipeline configured
+// receive frame re-encoded through it instead of the raw bytes, and are
+// skipped entirely if their pipeline rate-limits the frame away. Targets
+// without a pipeline always receive the raw bytes, which are copied once
+// and shared across them so callers may reuse or overwrite data
+// immediately after Send returns. It returns the number of targets the
+// packet was queued for (queueing can still result in an overflow drop)
+// and the total number of registered targets.
+func (m *Manager) Send(data []byte, frame *ifm.Frame) (queued int, total int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.workers) == 0 {
+		return 0, 0
+	}
+	recvTime := time.Now()
+
+	var rawCopy []byte
+	for _, w := range m.workers {
+		payload := data
+		if w.target.IFM != nil && frame != nil {
+			out, emit := w.target.IFM.Process(frame, recvTime)
+			if !emit {
+				continue
+			}
+			payload = out
+		} else {
+			if rawCopy == nil {
+				rawCopy = make([]byte, len(data))
+				copy(rawCopy, data)
+			}
+			payload = rawCopy
+		}
+		w.enqueue(job{data: payload, recvTime: recvTime})
+		queued++
+	}
+	return queued, len(m.workers)
+}
+
+// Snapshot returns a point-in-time copy of every target's counters and
+// current queue depth.
+func (m *Manager) Snapshot() []Snapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snaps := make([]Snapshot, 0, len(m.workers))
+	for name, w := range m.workers {
+		snap := Snapshot{
+			Name:            name,
+			Forwarded:       atomic.LoadInt64(&w.stats.Forwarded),
+			DroppedOverflow: atomic.LoadInt64(&w.stats.DroppedOverflow),
+			WriteErrors:     atomic.LoadInt64(&w.stats.WriteErrors),
+			AvgLatencyNs:    w.stats.AvgLatencyNs(),
+			QueueDepth:      len(w.ch),
+			QueueCapacity:   cap(w.ch),
+		}
+		if w.target.IFM != nil {
+			snap.FilterDrops, snap.RateLimited = w.target.IFM.Stats()
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps
+}
+
+// Close stops all workers.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, w := range m.workers {
+		w.stop()
+		delete(m.workers, name)
+	}
+}
@@ -0,0 +1,157 @@
+package forwarder
+
+import (
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whykusanagi/VTuberHub/internal/ifm"
+	"github.com/whykusanagi/VTuberHub/internal/logging"
+)
+
+type countingWriter struct {
+	mu    sync.Mutex
+	block chan struct{}
+	n     int
+}
+
+func (c *countingWriter) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	if c.block != nil {
+		<-c.block
+	}
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+	return len(b), nil
+}
+
+func (c *countingWriter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func testLogger() *logging.Logger {
+	return logging.New(os.Stderr, logging.FormatText, logging.LevelError, nil)
+}
+
+func TestManagerSendDeliversToAllTargets(t *testing.T) {
+	conn := &countingWriter{}
+	mgr := NewManager(conn, testLogger())
+	defer mgr.Close()
+
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
+	if err := mgr.AddTarget(Target{Name: "a", Addr: addr, QueueSize: 4}); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+	if err := mgr.AddTarget(Target{Name: "b", Addr: addr, QueueSize: 4}); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+
+	queued, total := mgr.Send([]byte("hello"), nil)
+	if queued != 2 || total != 2 {
+		t.Fatalf("expected queued=2 total=2, got queued=%d total=%d", queued, total)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for conn.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := conn.count(); got != 2 {
+		t.Fatalf("expected both targets to receive the packet, got %d writes", got)
+	}
+}
+
+func TestAddTargetRejectsDuplicateName(t *testing.T) {
+	mgr := NewManager(&countingWriter{}, testLogger())
+	defer mgr.Close()
+
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
+	if err := mgr.AddTarget(Target{Name: "a", Addr: addr, QueueSize: 4}); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+	if err := mgr.AddTarget(Target{Name: "a", Addr: addr, QueueSize: 4}); err == nil {
+		t.Fatalf("expected error for duplicate target name")
+	}
+}
+
+func TestOverflowDropNewestDropsIncomingPacket(t *testing.T) {
+	conn := &countingWriter{block: make(chan struct{})}
+	mgr := NewManager(conn, testLogger())
+	defer mgr.Close()
+	defer close(conn.block)
+
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
+	if err := mgr.AddTarget(Target{Name: "slow", Addr: addr, QueueSize: 1, Policy: DropNewest}); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+
+	// Fill the worker's in-flight slot and its one-deep queue, then overflow it.
+	mgr.Send([]byte("first"), nil) // picked up by the blocked worker goroutine
+	time.Sleep(20 * time.Millisecond)
+	mgr.Send([]byte("second"), nil) // fills the queue
+	mgr.Send([]byte("third"), nil)  // should overflow and be dropped
+
+	time.Sleep(20 * time.Millisecond)
+	snaps := mgr.Snapshot()
+	if len(snaps) != 1 {
+		t.Fatalf("expected 1 snapshot, got %d", len(snaps))
+	}
+	if snaps[0].DroppedOverflow != 1 {
+		t.Fatalf("expected 1 dropped packet, got %d", snaps[0].DroppedOverflow)
+	}
+}
+
+func TestSendAppliesPerTargetIFMPipeline(t *testing.T) {
+	conn := &countingWriter{}
+	mgr := NewManager(conn, testLogger())
+	defer mgr.Close()
+
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
+	pipeline := ifm.NewPipeline(ifm.PipelineConfig{Allow: []string{"jawOpen"}})
+	if err := mgr.AddTarget(Target{Name: "filtered", Addr: addr, QueueSize: 4, IFM: pipeline}); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+	if err := mgr.AddTarget(Target{Name: "raw", Addr: addr, QueueSize: 4}); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+
+	frame := &ifm.Frame{Blendshapes: map[string]float64{"jawOpen": 0.5, "eyeBlinkL": 0.9}}
+	queued, total := mgr.Send([]byte("raw-bytes"), frame)
+	if queued != 2 || total != 2 {
+		t.Fatalf("expected queued=2 total=2, got queued=%d total=%d", queued, total)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for conn.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := conn.count(); got != 2 {
+		t.Fatalf("expected both targets to receive a packet, got %d writes", got)
+	}
+
+	filterDrops, _ := pipeline.Stats()
+	if filterDrops != 1 {
+		t.Fatalf("expected the pipeline to drop eyeBlinkL, got %d drops", filterDrops)
+	}
+}
+
+func TestRemoveTarget(t *testing.T) {
+	mgr := NewManager(&countingWriter{}, testLogger())
+	defer mgr.Close()
+
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
+	mgr.AddTarget(Target{Name: "a", Addr: addr, QueueSize: 4})
+
+	if !mgr.RemoveTarget("a") {
+		t.Fatalf("expected RemoveTarget to report the target existed")
+	}
+	if mgr.RemoveTarget("a") {
+		t.Fatalf("expected second RemoveTarget to report nothing to remove")
+	}
+	if names := mgr.Names(); len(names) != 0 {
+		t.Fatalf("expected no targets remaining, got %v", names)
+	}
+}
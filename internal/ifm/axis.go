@@ -0,0 +1,62 @@
+package ifm
+
+import "fmt"
+
+// AxisSource names one component of a source Rotation and a sign to apply
+// to it, e.g. {From: "yaw", Sign: -1} to flip yaw's direction.
+type AxisSource struct {
+	From string
+	Sign float64
+}
+
+// AxisRemap maps each output rotation axis (pitch, yaw, roll) to a
+// (possibly different, possibly sign-flipped) source axis. This lets
+// operators match a downstream renderer's coordinate convention without
+// modifying the iFacialMocap app itself.
+type AxisRemap struct {
+	Pitch AxisSource
+	Yaw   AxisSource
+	Roll  AxisSource
+}
+
+// IdentityAxisRemap passes each axis through unchanged.
+var IdentityAxisRemap = AxisRemap{
+	Pitch: AxisSource{From: "pitch", Sign: 1},
+	Yaw:   AxisSource{From: "yaw", Sign: 1},
+	Roll:  AxisSource{From: "roll", Sign: 1},
+}
+
+// Apply produces the remapped Rotation from an input Rotation.
+func (r AxisRemap) Apply(in Rotation) Rotation {
+	return Rotation{
+		Pitch: axisValue(in, r.Pitch),
+		Yaw:   axisValue(in, r.Yaw),
+		Roll:  axisValue(in, r.Roll),
+	}
+}
+
+func axisValue(in Rotation, src AxisSource) float64 {
+	sign := src.Sign
+	if sign == 0 {
+		sign = 1
+	}
+	switch src.From {
+	case "yaw":
+		return sign * in.Yaw
+	case "roll":
+		return sign * in.Roll
+	default:
+		return sign * in.Pitch
+	}
+}
+
+// ValidateAxisSource reports whether name is a recognized rotation axis
+// name ("pitch", "yaw", or "roll"), for config validation.
+func ValidateAxisSource(name string) error {
+	switch name {
+	case "pitch", "yaw", "roll":
+		return nil
+	default:
+		return fmt.Errorf("ifm: unknown axis %q (want pitch, yaw, or roll)", name)
+	}
+}
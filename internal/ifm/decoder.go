@@ -0,0 +1,29 @@
+package ifm
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Decoder wraps Parse, counting the packets it fails to recognize as
+// iFacialMocap traffic so callers can report a ParseErrors stat alongside
+// their raw-forwarding fallback.
+type Decoder struct {
+	parseErrors int64
+}
+
+// Decode parses data into a Frame, counting the attempt against
+// ParseErrors on failure.
+func (d *Decoder) Decode(data []byte, now time.Time) (*Frame, error) {
+	frame, err := Parse(data, now)
+	if err != nil {
+		atomic.AddInt64(&d.parseErrors, 1)
+		return nil, err
+	}
+	return frame, nil
+}
+
+// ParseErrors returns the running count of packets Decode failed to parse.
+func (d *Decoder) ParseErrors() int64 {
+	return atomic.LoadInt64(&d.parseErrors)
+}
@@ -0,0 +1,146 @@
+// Package ifm parses and re-serializes the ASCII blendshape/bone packet
+// format sent by the iFacialMocap iOS app, and applies per-target
+// transforms (blendshape allow/deny lists, axis remapping, one-euro
+// smoothing, and output frame-rate limiting) so different consumers can
+// be fed different views of the same motion-capture stream.
+package ifm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rotation is a pitch/yaw/roll triple, in the units iFacialMocap sends
+// (degrees).
+type Rotation struct {
+	Pitch float64
+	Yaw   float64
+	Roll  float64
+}
+
+// Frame is a single parsed iFacialMocap sample.
+type Frame struct {
+	Blendshapes map[string]float64
+	Head        Rotation
+	RightEye    Rotation
+	LeftEye     Rotation
+	Timestamp   time.Time
+}
+
+const (
+	headPrefix     = "=head#"
+	rightEyePrefix = "rightEye#"
+	leftEyePrefix  = "leftEye#"
+)
+
+// Parse decodes a raw iFacialMocap UDP packet into a Frame. now is stamped
+// onto the Frame as its Timestamp (the wire format carries no timestamp of
+// its own). Parse returns an error for anything that isn't recognizable
+// iFacialMocap traffic, so callers can fall back to raw forwarding.
+func Parse(data []byte, now time.Time) (*Frame, error) {
+	s := strings.Trim(string(data), "&\r\n")
+	if s == "" {
+		return nil, errors.New("ifm: empty packet")
+	}
+
+	frame := &Frame{Blendshapes: make(map[string]float64), Timestamp: now}
+	recognized := 0
+
+	for _, field := range strings.Split(s, "|") {
+		if field == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(field, headPrefix):
+			vals, err := parseFloats(field[len(headPrefix):], 6)
+			if err != nil {
+				return nil, fmt.Errorf("ifm: parse head: %w", err)
+			}
+			frame.Head = Rotation{Pitch: vals[0], Yaw: vals[1], Roll: vals[2]}
+			recognized++
+		case strings.HasPrefix(field, rightEyePrefix):
+			vals, err := parseFloats(field[len(rightEyePrefix):], 3)
+			if err != nil {
+				return nil, fmt.Errorf("ifm: parse rightEye: %w", err)
+			}
+			frame.RightEye = Rotation{Pitch: vals[0], Yaw: vals[1], Roll: vals[2]}
+			recognized++
+		case strings.HasPrefix(field, leftEyePrefix):
+			vals, err := parseFloats(field[len(leftEyePrefix):], 3)
+			if err != nil {
+				return nil, fmt.Errorf("ifm: parse leftEye: %w", err)
+			}
+			frame.LeftEye = Rotation{Pitch: vals[0], Yaw: vals[1], Roll: vals[2]}
+			recognized++
+		default:
+			name, value, err := parseBlendshape(field)
+			if err != nil {
+				return nil, err
+			}
+			frame.Blendshapes[name] = value
+			recognized++
+		}
+	}
+
+	if recognized == 0 {
+		return nil, errors.New("ifm: no recognized fields")
+	}
+	return frame, nil
+}
+
+// parseBlendshape splits a "name-value" field. Blendshape weights are
+// always non-negative in the iFacialMocap protocol, so the last '-' in
+// the field is unambiguously the name/value separator.
+func parseBlendshape(field string) (string, float64, error) {
+	idx := strings.LastIndex(field, "-")
+	if idx <= 0 || idx == len(field)-1 {
+		return "", 0, fmt.Errorf("ifm: malformed blendshape field %q", field)
+	}
+	value, err := strconv.ParseFloat(field[idx+1:], 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("ifm: parse blendshape %q: %w", field, err)
+	}
+	return field[:idx], value, nil
+}
+
+func parseFloats(s string, want int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != want {
+		return nil, fmt.Errorf("expected %d values, got %d", want, len(parts))
+	}
+	vals := make([]float64, want)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		vals[i] = v
+	}
+	return vals, nil
+}
+
+// Encode re-serializes a Frame into the iFacialMocap wire format. Blendshape
+// fields are emitted in sorted order for deterministic output.
+func Encode(f *Frame) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s%g,%g,%g,0,0,0|", headPrefix, f.Head.Pitch, f.Head.Yaw, f.Head.Roll)
+	fmt.Fprintf(&b, "%s%g,%g,%g|", rightEyePrefix, f.RightEye.Pitch, f.RightEye.Yaw, f.RightEye.Roll)
+	fmt.Fprintf(&b, "%s%g,%g,%g", leftEyePrefix, f.LeftEye.Pitch, f.LeftEye.Yaw, f.LeftEye.Roll)
+
+	names := make([]string, 0, len(f.Blendshapes))
+	for name := range f.Blendshapes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "|%s-%g", name, f.Blendshapes[name])
+	}
+	b.WriteByte('&')
+
+	return []byte(b.String())
+}
@@ -0,0 +1,218 @@
+package ifm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseAndEncodeRoundTrip(t *testing.T) {
+	raw := "=head#1,2,3,0,0,0|rightEye#4,5,6|leftEye#7,8,9|browInnerUp-0.5|jawOpen-0.25&"
+	now := time.Unix(0, 0)
+
+	frame, err := Parse([]byte(raw), now)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if frame.Head != (Rotation{Pitch: 1, Yaw: 2, Roll: 3}) {
+		t.Fatalf("unexpected head rotation: %+v", frame.Head)
+	}
+	if frame.RightEye != (Rotation{Pitch: 4, Yaw: 5, Roll: 6}) {
+		t.Fatalf("unexpected rightEye rotation: %+v", frame.RightEye)
+	}
+	if frame.LeftEye != (Rotation{Pitch: 7, Yaw: 8, Roll: 9}) {
+		t.Fatalf("unexpected leftEye rotation: %+v", frame.LeftEye)
+	}
+	if frame.Blendshapes["browInnerUp"] != 0.5 || frame.Blendshapes["jawOpen"] != 0.25 {
+		t.Fatalf("unexpected blendshapes: %+v", frame.Blendshapes)
+	}
+
+	encoded := Encode(frame)
+	again, err := Parse(encoded, now)
+	if err != nil {
+		t.Fatalf("re-parsing encoded frame failed: %v", err)
+	}
+	if again.Head != frame.Head || again.RightEye != frame.RightEye || again.LeftEye != frame.LeftEye {
+		t.Fatalf("round-trip changed rotations: got %+v", again)
+	}
+	for name, value := range frame.Blendshapes {
+		if again.Blendshapes[name] != value {
+			t.Fatalf("round-trip changed blendshape %q: got %v want %v", name, again.Blendshapes[name], value)
+		}
+	}
+}
+
+func TestParseRejectsUnrecognizedPacket(t *testing.T) {
+	if _, err := Parse([]byte("not an ifm packet"), time.Now()); err == nil {
+		t.Fatalf("expected an error for an unrecognized packet")
+	}
+}
+
+func TestParseRejectsMalformedBlendshape(t *testing.T) {
+	if _, err := Parse([]byte("noSeparator&"), time.Now()); err == nil {
+		t.Fatalf("expected an error for a field with no '-' separator")
+	}
+}
+
+func TestOneEuroFilterSmoothsNoise(t *testing.T) {
+	cfg := DefaultOneEuroConfig
+	var s oneEuroState
+	t0 := time.Unix(0, 0)
+
+	first := s.filter(0, t0, cfg)
+	if first != 0 {
+		t.Fatalf("expected first sample to pass through unchanged, got %v", first)
+	}
+
+	// A large one-off jump should be pulled toward the previous value
+	// rather than passed straight through.
+	jumped := s.filter(10, t0.Add(33*time.Millisecond), cfg)
+	if jumped <= 0 || jumped >= 10 {
+		t.Fatalf("expected filtered value to lag behind the raw jump, got %v", jumped)
+	}
+}
+
+func TestSmootherTracksChannelsIndependently(t *testing.T) {
+	sm := NewSmoother()
+	t0 := time.Unix(0, 0)
+
+	sm.Smooth("a", 0, t0, DefaultOneEuroConfig)
+	sm.Smooth("b", 100, t0, DefaultOneEuroConfig)
+
+	a := sm.Smooth("a", 1, t0.Add(33*time.Millisecond), DefaultOneEuroConfig)
+	b := sm.Smooth("b", 100, t0.Add(33*time.Millisecond), DefaultOneEuroConfig)
+
+	if a >= 1 {
+		t.Fatalf("expected channel 'a' to still be lagging toward 0, got %v", a)
+	}
+	if math.Abs(b-100) > 1e-9 {
+		t.Fatalf("expected channel 'b' to stay at its steady value, got %v", b)
+	}
+}
+
+func TestAxisRemapAppliesSignAndSource(t *testing.T) {
+	remap := AxisRemap{
+		Pitch: AxisSource{From: "yaw", Sign: 1},
+		Yaw:   AxisSource{From: "pitch", Sign: -1},
+		Roll:  AxisSource{From: "roll", Sign: 1},
+	}
+	out := remap.Apply(Rotation{Pitch: 10, Yaw: 20, Roll: 30})
+
+	if out != (Rotation{Pitch: 20, Yaw: -10, Roll: 30}) {
+		t.Fatalf("unexpected remapped rotation: %+v", out)
+	}
+}
+
+func TestIdentityAxisRemapIsNoop(t *testing.T) {
+	in := Rotation{Pitch: 1, Yaw: 2, Roll: 3}
+	if out := IdentityAxisRemap.Apply(in); out != in {
+		t.Fatalf("identity remap changed rotation: got %+v want %+v", out, in)
+	}
+}
+
+func TestValidateAxisSourceRejectsUnknownAxis(t *testing.T) {
+	if err := ValidateAxisSource("tilt"); err == nil {
+		t.Fatalf("expected an error for an unknown axis name")
+	}
+	if err := ValidateAxisSource("yaw"); err != nil {
+		t.Fatalf("unexpected error for a valid axis name: %v", err)
+	}
+}
+
+func TestPipelineFiltersByAllowAndDeny(t *testing.T) {
+	p := NewPipeline(PipelineConfig{
+		Allow: []string{"jawOpen", "browInnerUp"},
+		Deny:  []string{"browInnerUp"},
+	})
+	frame := &Frame{Blendshapes: map[string]float64{
+		"jawOpen":     0.5,
+		"browInnerUp": 0.1,
+		"eyeBlinkL":   0.9,
+	}}
+
+	out, emitted := p.Process(frame, time.Now())
+	if !emitted {
+		t.Fatalf("expected frame to be emitted")
+	}
+
+	decoded, err := Parse(out, time.Now())
+	if err != nil {
+		t.Fatalf("failed to parse pipeline output: %v", err)
+	}
+	if _, ok := decoded.Blendshapes["jawOpen"]; !ok {
+		t.Fatalf("expected jawOpen to survive the allow list")
+	}
+	if _, ok := decoded.Blendshapes["browInnerUp"]; ok {
+		t.Fatalf("expected browInnerUp to be dropped by the deny list")
+	}
+	if _, ok := decoded.Blendshapes["eyeBlinkL"]; ok {
+		t.Fatalf("expected eyeBlinkL to be dropped by the allow list")
+	}
+
+	drops, _ := p.Stats()
+	if drops != 2 {
+		t.Fatalf("expected 2 filter drops, got %d", drops)
+	}
+}
+
+func TestPipelineRateLimitsOutput(t *testing.T) {
+	p := NewPipeline(PipelineConfig{MaxFPS: 10})
+	frame := &Frame{Blendshapes: map[string]float64{}}
+	t0 := time.Unix(0, 0)
+
+	if _, emitted := p.Process(frame, t0); !emitted {
+		t.Fatalf("expected the first frame to be emitted")
+	}
+	if _, emitted := p.Process(frame, t0.Add(20*time.Millisecond)); emitted {
+		t.Fatalf("expected a frame within the MaxFPS interval to be rate limited")
+	}
+	if _, emitted := p.Process(frame, t0.Add(200*time.Millisecond)); !emitted {
+		t.Fatalf("expected a frame after the MaxFPS interval to be emitted")
+	}
+
+	_, rateLimited := p.Stats()
+	if rateLimited != 1 {
+		t.Fatalf("expected 1 rate-limited frame, got %d", rateLimited)
+	}
+}
+
+func TestPipelineAppliesAxisRemap(t *testing.T) {
+	p := NewPipeline(PipelineConfig{
+		AxisRemap: AxisRemap{
+			Pitch: AxisSource{From: "pitch", Sign: -1},
+			Yaw:   AxisSource{From: "yaw", Sign: 1},
+			Roll:  AxisSource{From: "roll", Sign: 1},
+		},
+	})
+	frame := &Frame{
+		Blendshapes: map[string]float64{},
+		Head:        Rotation{Pitch: 5, Yaw: 10, Roll: 15},
+	}
+
+	out, emitted := p.Process(frame, time.Now())
+	if !emitted {
+		t.Fatalf("expected frame to be emitted")
+	}
+	decoded, err := Parse(out, time.Now())
+	if err != nil {
+		t.Fatalf("failed to parse pipeline output: %v", err)
+	}
+	if decoded.Head.Pitch != -5 {
+		t.Fatalf("expected remapped pitch -5, got %v", decoded.Head.Pitch)
+	}
+}
+
+func TestDecoderCountsParseErrors(t *testing.T) {
+	var d Decoder
+
+	if _, err := d.Decode([]byte("=head#1,2,3,0,0,0&"), time.Now()); err != nil {
+		t.Fatalf("unexpected error decoding a valid packet: %v", err)
+	}
+	if _, err := d.Decode([]byte("garbage"), time.Now()); err == nil {
+		t.Fatalf("expected an error decoding garbage")
+	}
+
+	if got := d.ParseErrors(); got != 1 {
+		t.Fatalf("expected 1 parse error, got %d", got)
+	}
+}
@@ -0,0 +1,89 @@
+package ifm
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// OneEuroConfig holds the tunable parameters of a One Euro Filter
+// (Casiez et al., 2012): MinCutoff is the baseline cutoff frequency
+// applied when the signal is still, Beta increases the cutoff (reduces
+// lag) in proportion to the speed of movement, and DCutoff is the cutoff
+// used to smooth the derivative itself.
+type OneEuroConfig struct {
+	MinCutoff float64
+	Beta      float64
+	DCutoff   float64
+}
+
+// DefaultOneEuroConfig matches the values iFacialMocap operators
+// typically start from.
+var DefaultOneEuroConfig = OneEuroConfig{MinCutoff: 1.0, Beta: 0.007, DCutoff: 1.0}
+
+type oneEuroState struct {
+	initialized bool
+	xPrev       float64
+	dxPrev      float64
+	tPrev       time.Time
+}
+
+func (s *oneEuroState) filter(x float64, t time.Time, cfg OneEuroConfig) float64 {
+	if !s.initialized {
+		s.xPrev = x
+		s.dxPrev = 0
+		s.tPrev = t
+		s.initialized = true
+		return x
+	}
+
+	dt := t.Sub(s.tPrev).Seconds()
+	if dt <= 0 {
+		return s.xPrev
+	}
+
+	dx := (x - s.xPrev) / dt
+	dxHat := lowPass(dx, s.dxPrev, lowPassAlpha(cfg.DCutoff, dt))
+
+	fc := cfg.MinCutoff + cfg.Beta*math.Abs(dxHat)
+	xHat := lowPass(x, s.xPrev, lowPassAlpha(fc, dt))
+
+	s.xPrev = xHat
+	s.dxPrev = dxHat
+	s.tPrev = t
+	return xHat
+}
+
+func lowPassAlpha(cutoff, dt float64) float64 {
+	return 1.0 / (1.0 + 1.0/(2*math.Pi*cutoff*dt))
+}
+
+func lowPass(x, prevHat, alpha float64) float64 {
+	return alpha*x + (1-alpha)*prevHat
+}
+
+// Smoother runs an independent one-euro filter per named channel (a
+// blendshape, or a "head.pitch"-style rotation component), creating state
+// lazily on first use. It is safe for concurrent use.
+type Smoother struct {
+	mu     sync.Mutex
+	states map[string]*oneEuroState
+}
+
+// NewSmoother creates an empty Smoother.
+func NewSmoother() *Smoother {
+	return &Smoother{states: make(map[string]*oneEuroState)}
+}
+
+// Smooth filters x for the given channel at time t using cfg.
+func (s *Smoother) Smooth(channel string, x float64, t time.Time, cfg OneEuroConfig) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.states[channel]
+	if !ok {
+		st = &oneEuroState{}
+		s.states[channel] = st
+	}
+	return st.filter(x, t, cfg)
+}
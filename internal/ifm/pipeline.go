@@ -0,0 +1,144 @@
+package ifm
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PipelineConfig describes the per-target transform to apply to parsed
+// frames before forwarding.
+type PipelineConfig struct {
+	// Allow, if non-empty, is the set of blendshape names to forward;
+	// everything else is dropped. Deny is checked after Allow and drops
+	// any blendshape named in it.
+	Allow []string
+	Deny  []string
+
+	AxisRemap AxisRemap
+
+	// Smoothing, if non-nil, one-euro-filters every blendshape and
+	// rotation component independently.
+	Smoothing *OneEuroConfig
+
+	// MaxFPS caps how often Process emits a frame for this target; 0
+	// means unlimited (every parsed frame is forwarded).
+	MaxFPS float64
+}
+
+// Pipeline applies a PipelineConfig to a stream of Frames for one target.
+// It is safe for concurrent use.
+type Pipeline struct {
+	allow map[string]bool
+	deny  map[string]bool
+	remap AxisRemap
+
+	smoothing *OneEuroConfig
+	smoother  *Smoother
+
+	minInterval time.Duration
+	mu          sync.Mutex
+	lastEmit    time.Time
+
+	filterDrops int64
+	rateLimited int64
+}
+
+// NewPipeline builds a Pipeline from cfg.
+func NewPipeline(cfg PipelineConfig) *Pipeline {
+	p := &Pipeline{
+		remap:     cfg.AxisRemap,
+		smoothing: cfg.Smoothing,
+	}
+	if len(cfg.Allow) > 0 {
+		p.allow = toSet(cfg.Allow)
+	}
+	if len(cfg.Deny) > 0 {
+		p.deny = toSet(cfg.Deny)
+	}
+	if cfg.Smoothing != nil {
+		p.smoother = NewSmoother()
+	}
+	if cfg.MaxFPS > 0 {
+		p.minInterval = time.Duration(float64(time.Second) / cfg.MaxFPS)
+	}
+	return p
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
+
+// Process applies the pipeline to frame at time now, returning the
+// re-encoded wire bytes and true, or (nil, false) if this frame should be
+// skipped for this target (it was rate-limited). frame is only read, not
+// mutated, so callers may share one Frame across every target's Pipeline.
+func (p *Pipeline) Process(frame *Frame, now time.Time) ([]byte, bool) {
+	if p.minInterval > 0 {
+		p.mu.Lock()
+		if !p.lastEmit.IsZero() && now.Sub(p.lastEmit) < p.minInterval {
+			p.mu.Unlock()
+			atomic.AddInt64(&p.rateLimited, 1)
+			return nil, false
+		}
+		p.lastEmit = now
+		p.mu.Unlock()
+	}
+
+	out := &Frame{
+		Blendshapes: make(map[string]float64, len(frame.Blendshapes)),
+		Timestamp:   frame.Timestamp,
+	}
+
+	for name, value := range frame.Blendshapes {
+		if p.dropped(name) {
+			atomic.AddInt64(&p.filterDrops, 1)
+			continue
+		}
+		if p.smoother != nil {
+			value = p.smoother.Smooth(name, value, now, *p.smoothing)
+		}
+		out.Blendshapes[name] = value
+	}
+
+	head, rightEye, leftEye := frame.Head, frame.RightEye, frame.LeftEye
+	if p.smoother != nil {
+		head = p.smoothRotation("head", head, now)
+		rightEye = p.smoothRotation("rightEye", rightEye, now)
+		leftEye = p.smoothRotation("leftEye", leftEye, now)
+	}
+	out.Head = p.remap.Apply(head)
+	out.RightEye = p.remap.Apply(rightEye)
+	out.LeftEye = p.remap.Apply(leftEye)
+
+	return Encode(out), true
+}
+
+func (p *Pipeline) smoothRotation(prefix string, r Rotation, now time.Time) Rotation {
+	return Rotation{
+		Pitch: p.smoother.Smooth(prefix+".pitch", r.Pitch, now, *p.smoothing),
+		Yaw:   p.smoother.Smooth(prefix+".yaw", r.Yaw, now, *p.smoothing),
+		Roll:  p.smoother.Smooth(prefix+".roll", r.Roll, now, *p.smoothing),
+	}
+}
+
+func (p *Pipeline) dropped(name string) bool {
+	if p.allow != nil && !p.allow[name] {
+		return true
+	}
+	if p.deny != nil && p.deny[name] {
+		return true
+	}
+	return false
+}
+
+// Stats returns this pipeline's running counters: the number of
+// blendshapes dropped by the allow/deny lists, and the number of frames
+// skipped by the MaxFPS rate limit.
+func (p *Pipeline) Stats() (filterDrops, rateLimited int64) {
+	return atomic.LoadInt64(&p.filterDrops), atomic.LoadInt64(&p.rateLimited)
+}
@@ -0,0 +1,298 @@
+// Package logging provides a small leveled, structured logger for the relay.
+//
+// It is intentionally modeled after the style of loggers like log15 and
+// syncthing's "l": a package-level default Logger with debug/info/warn/error
+// levels, key-value context fields, pluggable output formats (human-readable
+// or JSON), and per-subsystem trace areas gated by an env var so operators
+// can turn on noisy debug output for just the part of the relay they're
+// chasing (e.g. RELAYTRACE=forward,dump).
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is a logging severity level.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLevel parses a level name (case-insensitive). It defaults to
+// LevelInfo for an empty or unrecognized string.
+func ParseLevel(s string) Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug
+	case "warn", "warning":
+		return LevelWarn
+	case "error":
+		return LevelError
+	case "fatal":
+		return LevelFatal
+	default:
+		return LevelInfo
+	}
+}
+
+// Format selects how log lines are rendered.
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
+// ParseFormat parses a format name, defaulting to FormatText.
+func ParseFormat(s string) Format {
+	if strings.EqualFold(s, "json") {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// Logger is a leveled, structured logger. It is safe for concurrent use.
+//
+// Loggers are cheap to create: With and Named both return a new Logger that
+// shares the parent's output and level, so call sites can carry a
+// subsystem-scoped logger (e.g. the forwarder's per-target logger) without
+// any extra bookkeeping.
+type Logger struct {
+	mu       *sync.Mutex
+	out      io.Writer
+	format   Format
+	level    *Level // shared with the root logger so SetLevel affects all children
+	area     string
+	fields   []field
+	traceSet map[string]bool // nil means tracing is off entirely
+}
+
+type field struct {
+	key string
+	val interface{}
+}
+
+// New creates a root Logger writing to w in the given format at the given
+// level. traceAreas, if non-empty, enables debug-level output for loggers
+// named via Named(area) even when level is above debug, mirroring the
+// RELAYTRACE-style env var convention.
+func New(w io.Writer, format Format, level Level, traceAreas []string) *Logger {
+	lvl := level
+	var traceSet map[string]bool
+	if len(traceAreas) > 0 {
+		traceSet = make(map[string]bool, len(traceAreas))
+		for _, a := range traceAreas {
+			a = strings.TrimSpace(a)
+			if a != "" {
+				traceSet[a] = true
+			}
+		}
+	}
+	return &Logger{
+		mu:       &sync.Mutex{},
+		out:      w,
+		format:   format,
+		level:    &lvl,
+		traceSet: traceSet,
+	}
+}
+
+// Default is the package-level logger used by call sites that don't carry
+// their own Logger. It writes human-readable text at info level until
+// replaced (e.g. by main after parsing config) via SetDefault.
+var Default = New(os.Stderr, FormatText, LevelInfo, nil)
+
+// SetDefault replaces the package-level Default logger.
+func SetDefault(l *Logger) { Default = l }
+
+// SetLevel changes the minimum level the logger (and all of its children
+// produced via With/Named) will emit.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.level = level
+}
+
+// Level returns the logger's current minimum level.
+func (l *Logger) Level() Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return *l.level
+}
+
+// SetTraceAreas replaces the set of subsystem areas that get forced to
+// debug output regardless of the configured level. The set is copied into
+// children by Named and With, so it applies to this logger and to children
+// created after the call; children created earlier keep their old set.
+func (l *Logger) SetTraceAreas(areas []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(areas) == 0 {
+		l.traceSet = nil
+		return
+	}
+	set := make(map[string]bool, len(areas))
+	for _, a := range areas {
+		a = strings.TrimSpace(a)
+		if a != "" {
+			set[a] = true
+		}
+	}
+	l.traceSet = set
+}
+
+// Named returns a child logger scoped to the given subsystem area (e.g.
+// "forward", "dump", "stats"). Trace areas are matched against this name.
+func (l *Logger) Named(area string) *Logger {
+	child := *l
+	child.area = area
+	return &child
+}
+
+// With returns a child logger that always includes the given key-value
+// pairs as additional fields, e.g. log.With("target", name).Info("...").
+func (l *Logger) With(kvs ...interface{}) *Logger {
+	child := *l
+	child.fields = appendFields(l.fields, kvs)
+	return &child
+}
+
+func appendFields(base []field, kvs []interface{}) []field {
+	fields := make([]field, len(base), len(base)+len(kvs)/2+1)
+	copy(fields, base)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key, ok := kvs[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", kvs[i])
+		}
+		fields = append(fields, field{key: key, val: kvs[i+1]})
+	}
+	return fields
+}
+
+func (l *Logger) traced() bool {
+	return l.traceSet != nil && l.area != "" && l.traceSet[l.area]
+}
+
+func (l *Logger) enabled(level Level) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level == LevelDebug && l.traced() {
+		return true
+	}
+	return level >= *l.level
+}
+
+func (l *Logger) log(level Level, msg string, kvs []interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	fields := appendFields(l.fields, kvs)
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch l.format {
+	case FormatJSON:
+		writeJSON(l.out, now, level, l.area, msg, fields)
+	default:
+		writeText(l.out, now, level, l.area, msg, fields)
+	}
+}
+
+func writeText(w io.Writer, t time.Time, level Level, area, msg string, fields []field) {
+	var b strings.Builder
+	b.WriteString(t.Format("2006-01-02T15:04:05.000Z07:00"))
+	b.WriteByte(' ')
+	b.WriteString(strings.ToUpper(level.String()))
+	if area != "" {
+		b.WriteByte('[')
+		b.WriteString(area)
+		b.WriteByte(']')
+	}
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.key, f.val)
+	}
+	b.WriteByte('\n')
+	io.WriteString(w, b.String())
+}
+
+func writeJSON(w io.Writer, t time.Time, level Level, area, msg string, fields []field) {
+	entry := make(map[string]interface{}, len(fields)+4)
+	entry["time"] = t.Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	if area != "" {
+		entry["area"] = area
+	}
+	entry["msg"] = msg
+	for _, f := range fields {
+		entry[f.key] = f.val
+	}
+	enc := json.NewEncoder(w)
+	_ = enc.Encode(entry)
+}
+
+// Debug logs at debug level, or if the logger's area is in the trace set.
+func (l *Logger) Debug(msg string, kvs ...interface{}) { l.log(LevelDebug, msg, kvs) }
+
+// Info logs at info level.
+func (l *Logger) Info(msg string, kvs ...interface{}) { l.log(LevelInfo, msg, kvs) }
+
+// Warn logs at warn level.
+func (l *Logger) Warn(msg string, kvs ...interface{}) { l.log(LevelWarn, msg, kvs) }
+
+// Error logs at error level.
+func (l *Logger) Error(msg string, kvs ...interface{}) { l.log(LevelError, msg, kvs) }
+
+// Fatal logs at fatal level then calls os.Exit(1).
+func (l *Logger) Fatal(msg string, kvs ...interface{}) {
+	l.log(LevelFatal, msg, kvs)
+	os.Exit(1)
+}
+
+// TraceAreasFromEnv splits a RELAYTRACE-style env var value ("forward,dump")
+// into the area list expected by New/SetTraceAreas.
+func TraceAreasFromEnv(val string) []string {
+	if val == "" {
+		return nil
+	}
+	parts := strings.Split(val, ",")
+	areas := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			areas = append(areas, p)
+		}
+	}
+	return areas
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatText, LevelWarn, nil)
+
+	log.Info("should be dropped")
+	if buf.Len() != 0 {
+		t.Fatalf("expected info to be filtered at warn level, got %q", buf.String())
+	}
+
+	log.Warn("should appear")
+	if !strings.Contains(buf.String(), "should appear") {
+		t.Fatalf("expected warn message in output, got %q", buf.String())
+	}
+}
+
+func TestLevelFilteringAboveWarn(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatText, LevelFatal, nil)
+
+	log.Warn("should be dropped")
+	log.Error("should also be dropped")
+	if buf.Len() != 0 {
+		t.Fatalf("expected warn and error to be filtered at fatal level, got %q", buf.String())
+	}
+}
+
+func TestTraceAreaForcesDebug(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatText, LevelInfo, []string{"forward"})
+
+	log.Named("dump").Debug("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("expected untraced area to stay filtered, got %q", buf.String())
+	}
+
+	log.Named("forward").Debug("shown")
+	if !strings.Contains(buf.String(), "shown") {
+		t.Fatalf("expected traced area debug output, got %q", buf.String())
+	}
+}
+
+func TestJSONFormatIncludesFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatJSON, LevelInfo, nil)
+	log.With("target", "obs").Info("packet forwarded", "latency_us", 42)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode JSON log line: %v", err)
+	}
+	if entry["msg"] != "packet forwarded" || entry["target"] != "obs" {
+		t.Fatalf("unexpected JSON entry: %+v", entry)
+	}
+}
+
+func TestParseLevelAndFormat(t *testing.T) {
+	if ParseLevel("DEBUG") != LevelDebug {
+		t.Fatalf("expected case-insensitive level parsing")
+	}
+	if ParseLevel("") != LevelInfo {
+		t.Fatalf("expected empty level to default to info")
+	}
+	if ParseFormat("json") != FormatJSON {
+		t.Fatalf("expected json format to parse")
+	}
+	if ParseFormat("") != FormatText {
+		t.Fatalf("expected empty format to default to text")
+	}
+}
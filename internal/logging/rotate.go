@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.Writer that writes to a log file, rotating it to a
+// timestamped sibling before any write that would take it past
+// maxSizeBytes. It keeps the most
+// recent maxBackups rotated files and prunes older ones. A maxSizeBytes of
+// 0 disables rotation.
+type RotatingFile struct {
+	mu           sync.Mutex
+	path         string
+	maxSizeBytes int64
+	maxBackups   int
+	file         *os.File
+	size         int64
+}
+
+// OpenRotatingFile opens (creating if necessary) the log file at path for
+// appending, ready for size-based rotation.
+func OpenRotatingFile(path string, maxSizeBytes int64, maxBackups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil && filepath.Dir(path) != "." {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &RotatingFile{
+		path:         path,
+		maxSizeBytes: maxSizeBytes,
+		maxBackups:   maxBackups,
+		file:         f,
+		size:         info.Size(),
+	}, nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSizeBytes > 0 && r.size+int64(len(p)) > r.maxSizeBytes {
+		if err := r.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) rotateLocked() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	rotated := fmt.Sprintf("%s.%s", r.path, time.Now().Format("20060102-150405.000"))
+	if err := os.Rename(r.path, rotated); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.size = 0
+	r.pruneLocked()
+	return nil
+}
+
+func (r *RotatingFile) pruneLocked() {
+	if r.maxBackups <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil || len(matches) <= r.maxBackups {
+		return
+	}
+	// Glob returns lexically sorted results; our rotated suffix is a
+	// zero-padded timestamp, so lexical order matches chronological order.
+	excess := len(matches) - r.maxBackups
+	for _, old := range matches[:excess] {
+		os.Remove(old)
+	}
+}
+
+// Close closes the underlying file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package logging
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// SyslogWriter opens a connection to the local syslog daemon tagged with
+// the given process name, for use as (part of) a Logger's output.
+func SyslogWriter(tag string) (io.WriteCloser, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package logging
+
+import (
+	"errors"
+	"io"
+)
+
+// SyslogWriter is unsupported on Windows; there is no local syslog daemon.
+func SyslogWriter(tag string) (io.WriteCloser, error) {
+	return nil, errors.New("logging: syslog forwarding is not supported on windows")
+}
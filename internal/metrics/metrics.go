@@ -0,0 +1,225 @@
+// Package metrics exposes the relay's counters in Prometheus text format
+// and, optionally, mounts net/http/pprof handlers for live profiling. It
+// replaces the periodic stats log line with something Grafana/alerting can
+// scrape.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/whykusanagi/VTuberHub/internal/forwarder"
+)
+
+// packetSizeBuckets are the upper bounds (inclusive, in bytes) of the
+// packet-size histogram, following Prometheus's cumulative-bucket
+// convention. They span typical iFacialMocap UDP datagrams up to a
+// jumbo-frame ceiling.
+var packetSizeBuckets = []float64{64, 128, 256, 512, 1024, 1500, 2048, 4096, 8192}
+
+// Registry collects the values reported at /metrics. It is populated by
+// the relay's main loop and read back by the HTTP handler, so all state is
+// accessed atomically or behind read-only closures.
+type Registry struct {
+	startTime time.Time
+
+	packetsReceived func() int64
+	targetSnapshots func() []forwarder.Snapshot
+	resolvedTargets func() int
+	parseErrors     func() int64
+	readBufBytes    int
+	writeBufBytes   int
+
+	bucketCounts []int64 // cumulative, parallel to packetSizeBuckets, plus one +Inf bucket
+	sizeSum      int64
+	sizeCount    int64
+}
+
+// Options configures a new Registry. The function fields are called on
+// every /metrics scrape, so they should be cheap and non-blocking.
+type Options struct {
+	StartTime       time.Time
+	PacketsReceived func() int64
+	TargetSnapshots func() []forwarder.Snapshot
+	ResolvedTargets func() int
+	// ParseErrors reports how many received packets failed iFacialMocap
+	// parsing and fell back to raw forwarding. May be nil if the relay
+	// isn't decoding iFM frames at all.
+	ParseErrors   func() int64
+	ReadBufBytes  int
+	WriteBufBytes int
+}
+
+// NewRegistry creates a Registry from the given options.
+func NewRegistry(opts Options) *Registry {
+	return &Registry{
+		startTime:       opts.StartTime,
+		packetsReceived: opts.PacketsReceived,
+		targetSnapshots: opts.TargetSnapshots,
+		resolvedTargets: opts.ResolvedTargets,
+		parseErrors:     opts.ParseErrors,
+		readBufBytes:    opts.ReadBufBytes,
+		writeBufBytes:   opts.WriteBufBytes,
+		bucketCounts:    make([]int64, len(packetSizeBuckets)+1),
+	}
+}
+
+// RecordPacketSize adds a sample to the packet-size histogram.
+func (r *Registry) RecordPacketSize(n int) {
+	atomic.AddInt64(&r.sizeSum, int64(n))
+	atomic.AddInt64(&r.sizeCount, 1)
+
+	idx := sort.SearchFloat64s(packetSizeBuckets, float64(n))
+	for i := idx; i < len(r.bucketCounts); i++ {
+		atomic.AddInt64(&r.bucketCounts[i], 1)
+	}
+}
+
+// WriteTo renders all metrics in Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	var b countingWriter
+	b.w = w
+
+	fmt.Fprintf(&b, "# HELP vtuberhub_relay_packets_received_total Total UDP packets received.\n")
+	fmt.Fprintf(&b, "# TYPE vtuberhub_relay_packets_received_total counter\n")
+	fmt.Fprintf(&b, "vtuberhub_relay_packets_received_total %d\n", r.packetsReceived())
+
+	fmt.Fprintf(&b, "# HELP vtuberhub_relay_uptime_seconds Seconds since the relay started.\n")
+	fmt.Fprintf(&b, "# TYPE vtuberhub_relay_uptime_seconds gauge\n")
+	fmt.Fprintf(&b, "vtuberhub_relay_uptime_seconds %f\n", time.Since(r.startTime).Seconds())
+
+	fmt.Fprintf(&b, "# HELP vtuberhub_relay_targets_resolved Number of targets successfully resolved at startup.\n")
+	fmt.Fprintf(&b, "# TYPE vtuberhub_relay_targets_resolved gauge\n")
+	fmt.Fprintf(&b, "vtuberhub_relay_targets_resolved %d\n", r.resolvedTargets())
+
+	if r.parseErrors != nil {
+		fmt.Fprintf(&b, "# HELP vtuberhub_relay_ifm_parse_errors_total Packets that failed iFacialMocap parsing and fell back to raw forwarding.\n")
+		fmt.Fprintf(&b, "# TYPE vtuberhub_relay_ifm_parse_errors_total counter\n")
+		fmt.Fprintf(&b, "vtuberhub_relay_ifm_parse_errors_total %d\n", r.parseErrors())
+	}
+
+	fmt.Fprintf(&b, "# HELP vtuberhub_relay_udp_socket_buffer_bytes Configured UDP socket buffer size.\n")
+	fmt.Fprintf(&b, "# TYPE vtuberhub_relay_udp_socket_buffer_bytes gauge\n")
+	fmt.Fprintf(&b, "vtuberhub_relay_udp_socket_buffer_bytes{direction=\"read\"} %d\n", r.readBufBytes)
+	fmt.Fprintf(&b, "vtuberhub_relay_udp_socket_buffer_bytes{direction=\"write\"} %d\n", r.writeBufBytes)
+
+	r.writeTargetMetrics(&b)
+	r.writePacketSizeHistogram(&b)
+
+	return b.n, b.err
+}
+
+func (r *Registry) writeTargetMetrics(w io.Writer) {
+	snaps := r.targetSnapshots()
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_forwarded_total Packets successfully forwarded to a target.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_forwarded_total counter\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_forwarded_total{target=%q} %d\n", s.Name, s.Forwarded)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_dropped_overflow_total Packets dropped because a target's queue was full.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_dropped_overflow_total counter\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_dropped_overflow_total{target=%q} %d\n", s.Name, s.DroppedOverflow)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_write_errors_total UDP write errors for a target.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_write_errors_total counter\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_write_errors_total{target=%q} %d\n", s.Name, s.WriteErrors)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_avg_latency_seconds Average time between receive and forward for a target.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_avg_latency_seconds gauge\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_avg_latency_seconds{target=%q} %f\n", s.Name, float64(s.AvgLatencyNs)/1e9)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_queue_depth Current number of packets queued for a target.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_queue_depth gauge\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_queue_depth{target=%q} %d\n", s.Name, s.QueueDepth)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_queue_capacity Configured queue capacity for a target.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_queue_capacity gauge\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_queue_capacity{target=%q} %d\n", s.Name, s.QueueCapacity)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_ifm_filter_drops_total Blendshapes dropped by a target's IFM allow/deny list.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_ifm_filter_drops_total counter\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_ifm_filter_drops_total{target=%q} %d\n", s.Name, s.FilterDrops)
+	}
+
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_target_ifm_rate_limited_total Frames skipped by a target's IFM MaxFPS limit.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_target_ifm_rate_limited_total counter\n")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "vtuberhub_relay_target_ifm_rate_limited_total{target=%q} %d\n", s.Name, s.RateLimited)
+	}
+}
+
+func (r *Registry) writePacketSizeHistogram(w io.Writer) {
+	fmt.Fprintf(w, "# HELP vtuberhub_relay_packet_size_bytes Distribution of received packet sizes.\n")
+	fmt.Fprintf(w, "# TYPE vtuberhub_relay_packet_size_bytes histogram\n")
+	for i, le := range packetSizeBuckets {
+		fmt.Fprintf(w, "vtuberhub_relay_packet_size_bytes_bucket{le=%q} %d\n", formatBound(le), atomic.LoadInt64(&r.bucketCounts[i]))
+	}
+	fmt.Fprintf(w, "vtuberhub_relay_packet_size_bytes_bucket{le=\"+Inf\"} %d\n", atomic.LoadInt64(&r.bucketCounts[len(r.bucketCounts)-1]))
+	fmt.Fprintf(w, "vtuberhub_relay_packet_size_bytes_sum %d\n", atomic.LoadInt64(&r.sizeSum))
+	fmt.Fprintf(w, "vtuberhub_relay_packet_size_bytes_count %d\n", atomic.LoadInt64(&r.sizeCount))
+}
+
+func formatBound(f float64) string {
+	return fmt.Sprintf("%g", f)
+}
+
+// countingWriter wraps an io.Writer to accumulate the (bytes, error)
+// return value expected by io.WriterTo, short-circuiting once an error
+// occurs.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// ServeHTTP implements the /metrics endpoint.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+// NewMux builds the embedded metrics/pprof HTTP mux. Callers that need
+// additional routes (e.g. a reload admin endpoint) can register them on
+// the returned mux before wrapping it in an *http.Server.
+func NewMux(registry *Registry, enablePprof bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+
+	if enablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
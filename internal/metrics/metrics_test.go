@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whykusanagi/VTuberHub/internal/forwarder"
+)
+
+func newTestRegistry() *Registry {
+	return NewRegistry(Options{
+		StartTime:       time.Now().Add(-5 * time.Second),
+		PacketsReceived: func() int64 { return 42 },
+		TargetSnapshots: func() []forwarder.Snapshot {
+			return []forwarder.Snapshot{{Name: "obs", Forwarded: 10, QueueDepth: 1, QueueCapacity: 64}}
+		},
+		ResolvedTargets: func() int { return 1 },
+		ReadBufBytes:    65536,
+		WriteBufBytes:   65536,
+	})
+}
+
+func TestRegistryWriteToIncludesCounters(t *testing.T) {
+	reg := newTestRegistry()
+	reg.RecordPacketSize(120)
+	reg.RecordPacketSize(9000)
+
+	var buf bytes.Buffer
+	if _, err := reg.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"vtuberhub_relay_packets_received_total 42",
+		`vtuberhub_relay_target_forwarded_total{target="obs"} 10`,
+		"vtuberhub_relay_packet_size_bytes_count 2",
+		`vtuberhub_relay_packet_size_bytes_bucket{le="+Inf"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	reg := newTestRegistry()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+
+	reg.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "vtuberhub_relay_uptime_seconds") {
+		t.Fatalf("expected uptime gauge in response, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestNewMuxMountsPprofOnlyWhenEnabled(t *testing.T) {
+	reg := newTestRegistry()
+
+	withoutPprof := NewMux(reg, false)
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	rec := httptest.NewRecorder()
+	withoutPprof.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK {
+		t.Fatalf("expected pprof to be unmounted when disabled")
+	}
+
+	withPprof := NewMux(reg, true)
+	rec = httptest.NewRecorder()
+	withPprof.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected pprof index to be mounted when enabled, got %d", rec.Code)
+	}
+}
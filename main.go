@@ -5,45 +5,157 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"net"
+	"net/http"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/whykusanagi/VTuberHub/internal/dump"
+	"github.com/whykusanagi/VTuberHub/internal/forwarder"
+	"github.com/whykusanagi/VTuberHub/internal/ifm"
+	"github.com/whykusanagi/VTuberHub/internal/logging"
+	"github.com/whykusanagi/VTuberHub/internal/metrics"
 )
 
 // Config represents the relay configuration
 type Config struct {
-	ListenPort    int      `json:"listen_port"`
-	Targets       []Target `json:"targets"`
-	BufferSize    int      `json:"buffer_size"`
-	LogLevel      string   `json:"log_level"`
-	StatsInterval int      `json:"stats_interval"`
-	DumpPackets   bool     `json:"dump_packets"`
-	DumpDir       string   `json:"dump_dir"`
+	ListenPort         int      `json:"listen_port"`
+	Targets            []Target `json:"targets"`
+	BufferSize         int      `json:"buffer_size"`
+	LogLevel           string   `json:"log_level"`
+	LogFormat          string   `json:"log_format"`      // "text" (default) or "json"
+	LogFile            string   `json:"log_file"`        // empty means log to stderr
+	LogRotateSize      int64    `json:"log_rotate_size"` // bytes; 0 disables rotation
+	LogTraceAreas      string   `json:"log_trace_areas"` // comma-separated subsystem names, e.g. "forward,dump"
+	LogSyslog          bool     `json:"log_syslog"`
+	StatsInterval      int      `json:"stats_interval"`
+	DumpPackets        bool     `json:"dump_packets"`
+	DumpDir            string   `json:"dump_dir"`
+	DumpRotateSize     int64    `json:"dump_rotate_size"`     // bytes; 0 disables size-based rotation
+	DumpRotateInterval int      `json:"dump_rotate_interval"` // seconds; 0 disables duration-based rotation
+	DumpMaxFiles       int      `json:"dump_max_files"`       // 0 keeps every dump file
+	MetricsListen      string   `json:"metrics_listen"`       // e.g. ":9090"; empty disables the metrics/pprof server
+	EnablePprof        bool     `json:"enable_pprof"`
 }
 
 // Target represents a forwarding destination
 type Target struct {
-	Host string `json:"host"`
-	Port int    `json:"port"`
-	Name string `json:"name"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	Name           string `json:"name"`
+	QueueSize      int    `json:"queue_size"`      // per-target worker queue depth; defaults to 64
+	OverflowPolicy string `json:"overflow_policy"` // "drop_oldest" (default) or "drop_newest"
+
+	// IFM, if set, re-encodes the parsed iFacialMocap frame through a
+	// per-target pipeline (blendshape filtering, axis remap, smoothing,
+	// frame-rate limiting) before forwarding it to this target.
+	IFM *IFMConfig `json:"ifm"`
+}
+
+// IFMConfig configures a target's ifm.Pipeline.
+type IFMConfig struct {
+	Allow     []string         `json:"allow"`      // if non-empty, only these blendshapes are forwarded
+	Deny      []string         `json:"deny"`       // blendshapes to drop, checked after Allow
+	AxisRemap *AxisRemapConfig `json:"axis_remap"` // defaults to ifm.IdentityAxisRemap if unset
+	Smoothing *SmoothingConfig `json:"smoothing"`  // one-euro filter; unset disables smoothing
+	MaxFPS    float64          `json:"max_fps"`    // 0 means unlimited
+}
+
+// AxisRemapConfig maps this target's output pitch/yaw/roll axes to
+// (possibly different, possibly sign-flipped) source axes.
+type AxisRemapConfig struct {
+	Pitch AxisSourceConfig `json:"pitch"`
+	Yaw   AxisSourceConfig `json:"yaw"`
+	Roll  AxisSourceConfig `json:"roll"`
+}
+
+// AxisSourceConfig names a source axis ("pitch", "yaw", or "roll") and a
+// sign (1 or -1; 0 is treated as 1) to apply to it.
+type AxisSourceConfig struct {
+	From string  `json:"from"`
+	Sign float64 `json:"sign"`
+}
+
+// SmoothingConfig holds one-euro filter parameters. See ifm.OneEuroConfig
+// for what each field controls; e.g. MinCutoff 1.0, Beta 0.007, DCutoff 1.0
+// are reasonable starting values.
+type SmoothingConfig struct {
+	MinCutoff float64 `json:"min_cutoff"`
+	Beta      float64 `json:"beta"`
+	DCutoff   float64 `json:"d_cutoff"`
+}
+
+// buildPipeline translates a target's IFMConfig into an ifm.Pipeline, or
+// returns nil if the target has no IFM config.
+func buildPipeline(cfg *IFMConfig) *ifm.Pipeline {
+	if cfg == nil {
+		return nil
+	}
+	pcfg := ifm.PipelineConfig{
+		Allow:  cfg.Allow,
+		Deny:   cfg.Deny,
+		MaxFPS: cfg.MaxFPS,
+	}
+	if cfg.AxisRemap != nil {
+		pcfg.AxisRemap = ifm.AxisRemap{
+			Pitch: ifm.AxisSource{From: cfg.AxisRemap.Pitch.From, Sign: cfg.AxisRemap.Pitch.Sign},
+			Yaw:   ifm.AxisSource{From: cfg.AxisRemap.Yaw.From, Sign: cfg.AxisRemap.Yaw.Sign},
+			Roll:  ifm.AxisSource{From: cfg.AxisRemap.Roll.From, Sign: cfg.AxisRemap.Roll.Sign},
+		}
+	} else {
+		pcfg.AxisRemap = ifm.IdentityAxisRemap
+	}
+	if cfg.Smoothing != nil {
+		pcfg.Smoothing = &ifm.OneEuroConfig{
+			MinCutoff: cfg.Smoothing.MinCutoff,
+			Beta:      cfg.Smoothing.Beta,
+			DCutoff:   cfg.Smoothing.DCutoff,
+		}
+	}
+	return ifm.NewPipeline(pcfg)
 }
 
-// Stats tracks relay statistics
+// Stats tracks relay-wide statistics. Per-target forwarding counters live
+// in the forwarder package and are reported separately via
+// forwarder.Manager.Snapshot.
 type Stats struct {
-	PacketsReceived  int64
-	PacketsForwarded int64
-	PacketsDropped   int64
-	TotalLatencyNs   int64
-	PacketCount      int64
+	PacketsReceived int64
 }
 
+const udpSocketBufferBytes = 65536
+
 var (
 	stats     = &Stats{}
 	startTime = time.Now()
-	logLevel  = "info"
+
+	relayLog   = logging.Default
+	forwardLog = logging.Default
+	dumpLog    = logging.Default
+	statsLog   = logging.Default
+
+	// packetSizeRegistry is non-nil once the metrics server has started;
+	// the main loop uses it to feed the packet-size histogram.
+	packetSizeRegistry *metrics.Registry
+
+	// ifmDecoder parses every received packet as iFacialMocap traffic once,
+	// up front, so each target's pipeline doesn't re-parse it. Packets
+	// that don't parse fall back to raw forwarding for every target.
+	ifmDecoder ifm.Decoder
+
+	statsIntervalSeconds int32 // read/written atomically; live-reloadable
+
+	dumperMu sync.Mutex
+	dumper   *dump.Writer
+
+	// runtimeMu guards the fields below, which reloadConfig diffs against
+	// on every SIGHUP / admin reload so unchanged targets are left alone.
+	runtimeMu     sync.Mutex
+	listenPort    int
+	activeTargets = map[string]Target{}
 )
 
 func main() {
@@ -54,7 +166,7 @@ func main() {
 
 	config, err := loadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("[ERROR] Failed to load config: %v", err)
+		relayLog.Fatal("failed to load config", "error", err)
 	}
 
 	if *dumpDirFlag != "" {
@@ -65,63 +177,118 @@ func main() {
 	}
 
 	if err := config.normalize(); err != nil {
-		log.Fatalf("[ERROR] %v", err)
+		relayLog.Fatal(err.Error())
 	}
-	logLevel = config.LogLevel
 
-	var dumper *packetDumper
-	if config.DumpPackets {
-		pd, err := newPacketDumper(config.DumpDir)
-		if err != nil {
-			log.Fatalf("[ERROR] Failed to initialize packet dumper: %v", err)
-		}
-		dumper = pd
-		logInfo(fmt.Sprintf("Packet dumping enabled at %s", config.DumpDir))
+	root, err := newLogger(config)
+	if err != nil {
+		relayLog.Fatal("failed to initialize logging", "error", err)
 	}
+	logging.SetDefault(root)
+	relayLog = root
+	forwardLog = root.Named("forward")
+	dumpLog = root.Named("dump")
+	statsLog = root.Named("stats")
 
-	logInfo("iFacialMocap UDP Relay starting...")
-	logInfo(fmt.Sprintf("Listening on :%d", config.ListenPort))
-
-	// Resolve target addresses
-	targetAddrs := make([]*net.UDPAddr, 0, len(config.Targets))
-	for _, target := range config.Targets {
-		addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", target.Host, target.Port))
+	if config.DumpPackets {
+		pd, err := newPacketDumper(config)
 		if err != nil {
-			logError(fmt.Sprintf("Failed to resolve target %s (%s:%d): %v", target.Name, target.Host, target.Port, err))
-			continue
+			relayLog.Fatal("failed to initialize packet dumper", "error", err)
 		}
-		targetAddrs = append(targetAddrs, addr)
-		logInfo(fmt.Sprintf("Forwarding to %s:%d (%s)", target.Host, target.Port, target.Name))
+		dumperMu.Lock()
+		dumper = pd
+		dumperMu.Unlock()
+		relayLog.Info("packet dumping enabled", "dir", config.DumpDir)
 	}
 
-	if len(targetAddrs) == 0 {
-		log.Fatalf("[ERROR] No valid targets configured")
-	}
+	relayLog.Info("iFacialMocap UDP relay starting")
+	relayLog.Info("listening", "port", config.ListenPort)
 
 	// Create UDP listener
 	listenAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", config.ListenPort))
 	if err != nil {
-		log.Fatalf("[ERROR] Failed to resolve listen address: %v", err)
+		relayLog.Fatal("failed to resolve listen address", "error", err)
 	}
 
 	conn, err := net.ListenUDP("udp", listenAddr)
 	if err != nil {
-		log.Fatalf("[ERROR] Failed to listen on port %d: %v", config.ListenPort, err)
+		relayLog.Fatal("failed to listen", "port", config.ListenPort, "error", err)
 	}
 	defer conn.Close()
 
 	// Set socket options for better performance and compatibility
 	// Set buffer sizes to reduce packet loss
-	conn.SetReadBuffer(65536)  // 64KB read buffer
-	conn.SetWriteBuffer(65536) // 64KB write buffer
-	if logLevel == "debug" {
-		logDebug("UDP socket buffers set to 64KB")
+	conn.SetReadBuffer(udpSocketBufferBytes)  // 64KB read buffer
+	conn.SetWriteBuffer(udpSocketBufferBytes) // 64KB write buffer
+	relayLog.Debug("UDP socket buffers set", "size_bytes", udpSocketBufferBytes)
+
+	// Each target gets its own worker goroutine and bounded queue, so one
+	// slow or blocked target can't stall delivery to the others.
+	mgr := forwarder.NewManager(conn, relayLog)
+	defer mgr.Close()
+	resolvedTargets := 0
+
+	runtimeMu.Lock()
+	listenPort = config.ListenPort
+	runtimeMu.Unlock()
+
+	for _, target := range config.Targets {
+		addr, err := resolveTargetAddr(target)
+		if err != nil {
+			relayLog.Error("failed to resolve target", "target", target.Name, "host", target.Host, "port", target.Port, "error", err)
+			continue
+		}
+		err = mgr.AddTarget(forwarder.Target{
+			Name:      target.Name,
+			Addr:      addr,
+			QueueSize: target.QueueSize,
+			Policy:    forwarder.ParseOverflowPolicy(target.OverflowPolicy),
+			IFM:       buildPipeline(target.IFM),
+		})
+		if err != nil {
+			relayLog.Error("failed to add target", "target", target.Name, "error", err)
+			continue
+		}
+		resolvedTargets++
+		runtimeMu.Lock()
+		activeTargets[target.Name] = target
+		runtimeMu.Unlock()
+		relayLog.Info("forwarding to target", "target", target.Name, "host", target.Host, "port", target.Port)
+	}
+
+	if len(mgr.Names()) == 0 {
+		relayLog.Fatal("no valid targets configured")
+	}
+
+	atomic.StoreInt32(&statsIntervalSeconds, int32(config.StatsInterval))
+	go watchReloadSignals(*configPath, mgr)
+
+	if config.MetricsListen != "" {
+		registry := metrics.NewRegistry(metrics.Options{
+			StartTime:       startTime,
+			PacketsReceived: func() int64 { return atomic.LoadInt64(&stats.PacketsReceived) },
+			TargetSnapshots: mgr.Snapshot,
+			ResolvedTargets: func() int { return resolvedTargets },
+			ParseErrors:     ifmDecoder.ParseErrors,
+			ReadBufBytes:    udpSocketBufferBytes,
+			WriteBufBytes:   udpSocketBufferBytes,
+		})
+		packetSizeRegistry = registry
+		mux := metrics.NewMux(registry, config.EnablePprof)
+		mux.HandleFunc("/reload", handleReloadHTTP(*configPath, mgr))
+		srv := &http.Server{Addr: config.MetricsListen, Handler: mux}
+		go func() {
+			relayLog.Info("metrics server listening", "addr", config.MetricsListen, "pprof", config.EnablePprof)
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				relayLog.Error("metrics server stopped", "error", err)
+			}
+		}()
 	}
 
-	logInfo("Relay started successfully")
+	relayLog.Info("relay started successfully")
 
 	// Start stats reporting goroutine
-	go reportStats(config.StatsInterval)
+	go reportStats(mgr)
 
 	// Preallocate buffer
 	buffer := make([]byte, config.BufferSize)
@@ -130,48 +297,77 @@ func main() {
 	for {
 		n, srcAddr, err := conn.ReadFromUDP(buffer)
 		if err != nil {
-			logError(fmt.Sprintf("Read error: %v", err))
+			forwardLog.Error("read error", "error", err)
 			continue
 		}
 
 		// Track received packet
 		atomic.AddInt64(&stats.PacketsReceived, 1)
+		if packetSizeRegistry != nil {
+			packetSizeRegistry.RecordPacketSize(n)
+		}
 
-		if logLevel == "debug" {
-			logDebug(fmt.Sprintf("Received %d bytes from %s", n, srcAddr))
-			// Log first few bytes for debugging
-			if n > 0 && n < 100 {
-				logDebug(fmt.Sprintf("Packet preview: %s", string(buffer[:min(n, 50)])))
-			}
+		forwardLog.Debug("received packet", "bytes", n, "src", srcAddr)
+		if n > 0 && n < 100 {
+			forwardLog.Debug("packet preview", "src", srcAddr, "data", string(buffer[:min(n, 50)]))
 		}
 
 		packetData := buffer[:n]
 
-		if dumper != nil {
-			if err := dumper.Write(packetData); err != nil {
-				logError(fmt.Sprintf("Failed to dump packet: %v", err))
-			}
+		dumperMu.Lock()
+		d := dumper
+		dumperMu.Unlock()
+		if d != nil {
+			d.Write(time.Now(), srcAddr, packetData)
 		}
 
-		// Forward packet to all targets
-		start := time.Now()
-		successCount := forwardPacket(conn, packetData, targetAddrs)
-
-		// Track latency
-		latency := time.Since(start).Nanoseconds()
-		atomic.AddInt64(&stats.TotalLatencyNs, latency)
-		atomic.AddInt64(&stats.PacketCount, 1)
-
-		if successCount == len(targetAddrs) {
-			atomic.AddInt64(&stats.PacketsForwarded, 1)
-		} else {
-			dropped := int64(len(targetAddrs) - successCount)
-			atomic.AddInt64(&stats.PacketsDropped, dropped)
-			if logLevel == "debug" || logLevel == "info" {
-				logInfo(fmt.Sprintf("Partially forwarded packet: %d/%d targets", successCount, len(targetAddrs)))
-			}
+		frame, err := ifmDecoder.Decode(packetData, time.Now())
+		if err != nil {
+			forwardLog.Debug("not an iFacialMocap packet, forwarding raw", "src", srcAddr, "error", err)
+		}
+
+		queued, total := mgr.Send(packetData, frame)
+		forwardLog.Debug("packet forwarded", "src", srcAddr, "queued", queued, "targets", total)
+	}
+}
+
+// newLogger builds the root Logger from the relay config: output
+// destination (stderr, file with optional rotation, and/or syslog), format,
+// level, and trace areas.
+func newLogger(config *Config) (*logging.Logger, error) {
+	var writers []io.Writer
+
+	if config.LogFile != "" {
+		rf, err := logging.OpenRotatingFile(config.LogFile, config.LogRotateSize, 5)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		writers = append(writers, rf)
+	} else {
+		writers = append(writers, os.Stderr)
+	}
+
+	if config.LogSyslog {
+		sw, err := logging.SyslogWriter("vtuberhub-relay")
+		if err != nil {
+			return nil, fmt.Errorf("open syslog: %w", err)
 		}
+		writers = append(writers, sw)
 	}
+
+	var out io.Writer = writers[0]
+	if len(writers) > 1 {
+		out = io.MultiWriter(writers...)
+	}
+
+	format := logging.ParseFormat(config.LogFormat)
+	level := logging.ParseLevel(config.LogLevel)
+	traceAreas := logging.TraceAreasFromEnv(os.Getenv("RELAYTRACE"))
+	if config.LogTraceAreas != "" {
+		traceAreas = append(traceAreas, strings.Split(config.LogTraceAreas, ",")...)
+	}
+
+	return logging.New(out, format, level, traceAreas), nil
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -208,87 +404,99 @@ func (c *Config) normalize() error {
 	if c.DumpPackets && c.DumpDir == "" {
 		c.DumpDir = "raw_packets"
 	}
-	return nil
-}
-
-type udpWriter interface {
-	WriteToUDP(b []byte, addr *net.UDPAddr) (int, error)
-}
-
-func forwardPacket(conn udpWriter, data []byte, targets []*net.UDPAddr) int {
-	successCount := 0
-	// Forward sequentially; UDP writes are non-blocking and this keeps the implementation simple.
-	for _, targetAddr := range targets {
-		n, err := conn.WriteToUDP(data, targetAddr)
-		if err != nil {
-			logError(fmt.Sprintf("Failed to forward to %s: %v", targetAddr, err))
-		} else if n != len(data) {
-			logError(fmt.Sprintf("Partial write to %s: %d/%d bytes", targetAddr, n, len(data)))
-		} else {
-			successCount++
+	if c.DumpRotateSize < 0 {
+		c.DumpRotateSize = 0
+	}
+	if c.DumpRotateInterval < 0 {
+		c.DumpRotateInterval = 0
+	}
+	if c.DumpMaxFiles < 0 {
+		c.DumpMaxFiles = 0
+	}
+	for i := range c.Targets {
+		if c.Targets[i].QueueSize <= 0 {
+			c.Targets[i].QueueSize = 64
+		}
+		if c.Targets[i].OverflowPolicy == "" {
+			c.Targets[i].OverflowPolicy = "drop_oldest"
+		}
+		if remap := c.Targets[i].IFM; remap != nil && remap.AxisRemap != nil {
+			for _, src := range []AxisSourceConfig{remap.AxisRemap.Pitch, remap.AxisRemap.Yaw, remap.AxisRemap.Roll} {
+				if err := ifm.ValidateAxisSource(src.From); err != nil {
+					return fmt.Errorf("target %q: %w", c.Targets[i].Name, err)
+				}
+			}
 		}
 	}
-	return successCount
+	return nil
 }
 
-type packetDumper struct {
-	dir     string
-	counter uint64
+// newPacketDumper builds a dump.Writer from the relay config's dump
+// settings.
+func newPacketDumper(config *Config) (*dump.Writer, error) {
+	return dump.NewWriter(config.DumpDir, wantDumpOptions(config))
 }
 
-func newPacketDumper(dir string) (*packetDumper, error) {
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return nil, err
+// wantDumpOptions translates the relay config's dump settings into the
+// dump.Options a dumper for it should be using, so reload can detect
+// when rotation/retention knobs changed and the dumper needs recreating.
+func wantDumpOptions(config *Config) dump.Options {
+	return dump.Options{
+		RotateSize:     config.DumpRotateSize,
+		RotateInterval: time.Duration(config.DumpRotateInterval) * time.Second,
+		MaxFiles:       config.DumpMaxFiles,
 	}
-	return &packetDumper{dir: dir}, nil
 }
 
-func (d *packetDumper) Write(data []byte) error {
-	idx := atomic.AddUint64(&d.counter, 1)
-	ts := time.Now().Format("20060102-150405")
-	filename := fmt.Sprintf("packet-%s-%d.txt", ts, idx)
-	path := filepath.Join(d.dir, filename)
-	return os.WriteFile(path, data, 0o644)
+// resolveTargetAddr resolves a configured Target's host:port. It is a
+// package-level function (rather than inlined) so both the initial target
+// setup and reload's diff application can share the same resolution logic.
+func resolveTargetAddr(t Target) (*net.UDPAddr, error) {
+	return net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", t.Host, t.Port))
 }
 
-func reportStats(interval int) {
-	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+// reportStats polls statsIntervalSeconds every second rather than owning a
+// fixed-duration ticker, so a live reload that changes StatsInterval takes
+// effect without restarting the goroutine.
+func reportStats(mgr *forwarder.Manager) {
+	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	lastReport := time.Now()
 	for range ticker.C {
-		received := atomic.LoadInt64(&stats.PacketsReceived)
-		forwarded := atomic.LoadInt64(&stats.PacketsForwarded)
-		dropped := atomic.LoadInt64(&stats.PacketsDropped)
-		totalLatency := atomic.LoadInt64(&stats.TotalLatencyNs)
-		packetCount := atomic.LoadInt64(&stats.PacketCount)
-
-		var avgLatency float64
-		if packetCount > 0 {
-			avgLatency = float64(totalLatency) / float64(packetCount) / 1000000.0 // Convert to milliseconds
+		interval := time.Duration(atomic.LoadInt32(&statsIntervalSeconds)) * time.Second
+		if interval <= 0 || time.Since(lastReport) < interval {
+			continue
 		}
+		lastReport = time.Now()
 
+		received := atomic.LoadInt64(&stats.PacketsReceived)
 		uptime := time.Since(startTime).Round(time.Second)
-		logInfo(fmt.Sprintf("[STATS] Uptime: %s | Received: %d | Forwarded: %d | Dropped: %d | Avg Latency: %.3f ms",
-			uptime, received, forwarded, dropped, avgLatency))
-	}
-}
+		statsLog.Info("stats", "uptime", uptime, "received", received, "ifm_parse_errors", ifmDecoder.ParseErrors())
 
-func logInfo(msg string) {
-	if logLevel == "debug" || logLevel == "info" {
-		log.Printf("[INFO] %s", msg)
-	}
-}
+		dumperMu.Lock()
+		d := dumper
+		dumperMu.Unlock()
+		if d != nil {
+			dumpLog.Info("dump stats", "dropped", d.Dropped())
+		}
 
-func logDebug(msg string) {
-	if logLevel == "debug" {
-		log.Printf("[DEBUG] %s", msg)
+		for _, snap := range mgr.Snapshot() {
+			statsLog.Info("target stats",
+				"target", snap.Name,
+				"forwarded", snap.Forwarded,
+				"dropped_overflow", snap.DroppedOverflow,
+				"write_errors", snap.WriteErrors,
+				"avg_latency_us", snap.AvgLatencyNs/1000,
+				"queue_depth", snap.QueueDepth,
+				"queue_capacity", snap.QueueCapacity,
+				"ifm_filter_drops", snap.FilterDrops,
+				"ifm_rate_limited", snap.RateLimited,
+			)
+		}
 	}
 }
 
-func logError(msg string) {
-	log.Printf("[ERROR] %s", msg)
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"net"
 	"os"
 	"path/filepath"
 	"testing"
@@ -53,26 +52,21 @@ func TestLoadConfig(t *testing.T) {
 	}
 }
 
-type fakeWriter struct {
-	fail bool
-}
-
-func (f *fakeWriter) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
-	if f.fail {
-		return 0, os.ErrInvalid
+func TestConfigNormalizeDefaultsTargetQueues(t *testing.T) {
+	cfg := &Config{
+		ListenPort: 13121,
+		Targets: []Target{
+			{Host: "127.0.0.1", Port: 49983, Name: "a"},
+			{Host: "127.0.0.1", Port: 49984, Name: "b", QueueSize: 128, OverflowPolicy: "drop_newest"},
+		},
 	}
-	return len(b), nil
-}
-
-func TestForwardPacket(t *testing.T) {
-	target := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
-	success := forwardPacket(&fakeWriter{}, []byte("hello"), []*net.UDPAddr{target})
-	if success != 1 {
-		t.Fatalf("expected 1 success, got %d", success)
+	if err := cfg.normalize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-
-	failCount := forwardPacket(&fakeWriter{fail: true}, []byte("hello"), []*net.UDPAddr{target})
-	if failCount != 0 {
-		t.Fatalf("expected 0 success for failing writer")
+	if cfg.Targets[0].QueueSize != 64 || cfg.Targets[0].OverflowPolicy != "drop_oldest" {
+		t.Fatalf("expected default queue settings, got %+v", cfg.Targets[0])
+	}
+	if cfg.Targets[1].QueueSize != 128 || cfg.Targets[1].OverflowPolicy != "drop_newest" {
+		t.Fatalf("expected explicit queue settings preserved, got %+v", cfg.Targets[1])
 	}
 }
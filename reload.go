@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/whykusanagi/VTuberHub/internal/dump"
+	"github.com/whykusanagi/VTuberHub/internal/forwarder"
+	"github.com/whykusanagi/VTuberHub/internal/logging"
+)
+
+// workerRegistry is the subset of forwarder.Manager that reload needs.
+// Abstracting it lets the diff-application logic be tested against a fake
+// registry instead of real UDP workers.
+type workerRegistry interface {
+	AddTarget(forwarder.Target) error
+	RemoveTarget(name string) bool
+}
+
+// watchReloadSignals re-reads the config file and applies it every time
+// the process receives SIGHUP, until the process exits.
+func watchReloadSignals(configPath string, mgr workerRegistry) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	for range sigCh {
+		relayLog.Info("reload triggered", "signal", "SIGHUP")
+		if err := reloadConfig(configPath, mgr); err != nil {
+			relayLog.Error("reload failed", "error", err)
+			continue
+		}
+		relayLog.Info("reload applied")
+	}
+}
+
+// handleReloadHTTP returns a handler for the admin "POST /reload"
+// endpoint, which re-reads configPath and applies it the same way SIGHUP
+// does.
+func handleReloadHTTP(configPath string, mgr workerRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := reloadConfig(configPath, mgr); err != nil {
+			relayLog.Error("reload failed", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		relayLog.Info("reload applied", "via", "admin endpoint")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "reloaded")
+	}
+}
+
+// reloadConfig re-reads configPath and applies the result: targets are
+// diffed against the running set so workers for unchanged targets are
+// left alone, and LogLevel, StatsInterval, and DumpPackets are updated
+// live. A reload that would change ListenPort is rejected, since that
+// requires rebinding the listen socket and restarting the relay.
+func reloadConfig(configPath string, mgr workerRegistry) error {
+	newCfg, err := loadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	if err := newCfg.normalize(); err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+
+	if newCfg.ListenPort != listenPort {
+		return fmt.Errorf("reload: listen_port change from %d to %d requires a restart", listenPort, newCfg.ListenPort)
+	}
+
+	activeTargets = applyTargetDiff(mgr, activeTargets, newCfg.Targets, resolveTargetAddr)
+
+	relayLog.SetLevel(logging.ParseLevel(newCfg.LogLevel))
+	atomic.StoreInt32(&statsIntervalSeconds, int32(newCfg.StatsInterval))
+	applyDumpPackets(newCfg)
+
+	return nil
+}
+
+// applyDumpPackets starts or stops the packet dumper to match the
+// reloaded config's dump settings, closing the previous dumper's
+// background goroutine whenever it's replaced or disabled. The dumper is
+// recreated whenever the on/off flag, directory, or any of its rotation
+// and retention knobs (DumpRotateSize, DumpRotateInterval, DumpMaxFiles)
+// change.
+func applyDumpPackets(cfg *Config) {
+	dumperMu.Lock()
+	defer dumperMu.Unlock()
+
+	if !cfg.DumpPackets {
+		if dumper != nil {
+			dumper.Close()
+			dumper = nil
+		}
+		return
+	}
+	if dumper != nil && dumper.Dir() == cfg.DumpDir && dumpOptionsEqual(dumper.Options(), wantDumpOptions(cfg)) {
+		return
+	}
+	pd, err := newPacketDumper(cfg)
+	if err != nil {
+		relayLog.Error("failed to initialize packet dumper on reload", "error", err)
+		return
+	}
+	if dumper != nil {
+		dumper.Close()
+	}
+	dumper = pd
+	relayLog.Info("packet dumping enabled", "dir", cfg.DumpDir)
+}
+
+// dumpOptionsEqual reports whether a and b specify the same config-driven
+// rotation and retention policy. QueueSize isn't config-driven (NewWriter
+// fills in its default on the stored Options), so it's deliberately
+// excluded from the comparison.
+func dumpOptionsEqual(a, b dump.Options) bool {
+	return a.RotateSize == b.RotateSize &&
+		a.RotateInterval == b.RotateInterval &&
+		a.MaxFiles == b.MaxFiles
+}
+
+// diffTargets compares the currently active targets against the desired
+// list by name, returning targets that need to be added (new, or changed
+// in a way that requires recreating the worker) and the names of targets
+// that need to be removed.
+func diffTargets(active map[string]Target, desired []Target) (toAdd []Target, toRemove []string) {
+	desiredByName := make(map[string]Target, len(desired))
+	for _, t := range desired {
+		desiredByName[t.Name] = t
+	}
+
+	for name := range active {
+		if _, ok := desiredByName[name]; !ok {
+			toRemove = append(toRemove, name)
+		}
+	}
+
+	for name, t := range desiredByName {
+		old, ok := active[name]
+		if !ok {
+			toAdd = append(toAdd, t)
+			continue
+		}
+		if !targetConfigEqual(old, t) {
+			toRemove = append(toRemove, name)
+			toAdd = append(toAdd, t)
+		}
+	}
+
+	return toAdd, toRemove
+}
+
+func targetConfigEqual(a, b Target) bool {
+	return a.Host == b.Host && a.Port == b.Port && a.QueueSize == b.QueueSize &&
+		a.OverflowPolicy == b.OverflowPolicy && ifmConfigEqual(a.IFM, b.IFM)
+}
+
+func ifmConfigEqual(a, b *IFMConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return string(aj) == string(bj)
+}
+
+// applyTargetDiff removes stale workers and adds new/changed ones on mgr,
+// returning the new set of active targets. Targets that fail to resolve
+// or fail AddTarget are logged and left out of the result, same as at
+// startup.
+func applyTargetDiff(mgr workerRegistry, active map[string]Target, desired []Target, resolve func(Target) (*net.UDPAddr, error)) map[string]Target {
+	toAdd, toRemove := diffTargets(active, desired)
+
+	next := make(map[string]Target, len(active))
+	for name, t := range active {
+		next[name] = t
+	}
+
+	for _, name := range toRemove {
+		mgr.RemoveTarget(name)
+		delete(next, name)
+	}
+
+	for _, t := range toAdd {
+		addr, err := resolve(t)
+		if err != nil {
+			relayLog.Error("reload: failed to resolve target", "target", t.Name, "host", t.Host, "port", t.Port, "error", err)
+			continue
+		}
+		err = mgr.AddTarget(forwarder.Target{
+			Name:      t.Name,
+			Addr:      addr,
+			QueueSize: t.QueueSize,
+			Policy:    forwarder.ParseOverflowPolicy(t.OverflowPolicy),
+			IFM:       buildPipeline(t.IFM),
+		})
+		if err != nil {
+			relayLog.Error("reload: failed to add target", "target", t.Name, "error", err)
+			continue
+		}
+		next[t.Name] = t
+		relayLog.Info("reload: target added", "target", t.Name, "host", t.Host, "port", t.Port)
+	}
+
+	return next
+}
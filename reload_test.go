@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/whykusanagi/VTuberHub/internal/forwarder"
+)
+
+type fakeRegistry struct {
+	added   []forwarder.Target
+	removed []string
+	failAdd map[string]bool
+}
+
+func (f *fakeRegistry) AddTarget(t forwarder.Target) error {
+	if f.failAdd[t.Name] {
+		return errors.New("fake add failure")
+	}
+	f.added = append(f.added, t)
+	return nil
+}
+
+func (f *fakeRegistry) RemoveTarget(name string) bool {
+	f.removed = append(f.removed, name)
+	return true
+}
+
+func fakeResolve(t Target) (*net.UDPAddr, error) {
+	return &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: t.Port}, nil
+}
+
+func TestApplyDumpPacketsRecreatesOnRotationChange(t *testing.T) {
+	dumperMu.Lock()
+	dumper = nil
+	dumperMu.Unlock()
+	defer func() {
+		dumperMu.Lock()
+		if dumper != nil {
+			dumper.Close()
+			dumper = nil
+		}
+		dumperMu.Unlock()
+	}()
+
+	dir := t.TempDir()
+	cfg := &Config{DumpPackets: true, DumpDir: dir, DumpRotateSize: 1024}
+	applyDumpPackets(cfg)
+
+	dumperMu.Lock()
+	first := dumper
+	dumperMu.Unlock()
+	if first == nil {
+		t.Fatalf("expected a dumper to be created")
+	}
+
+	// Same dir, but a changed rotation knob: the dumper must be recreated,
+	// not silently left with the old rotation policy.
+	cfg.DumpRotateSize = 2048
+	applyDumpPackets(cfg)
+
+	dumperMu.Lock()
+	second := dumper
+	dumperMu.Unlock()
+	if second == first {
+		t.Fatalf("expected dumper to be recreated after a rotation option change")
+	}
+	if second.Options().RotateSize != 2048 {
+		t.Fatalf("expected new dumper to use the updated rotate size, got %d", second.Options().RotateSize)
+	}
+
+	// Nothing changed: the dumper must be left alone.
+	applyDumpPackets(cfg)
+	dumperMu.Lock()
+	third := dumper
+	dumperMu.Unlock()
+	if third != second {
+		t.Fatalf("expected dumper to be left unchanged when no dump settings changed")
+	}
+}
+
+func TestDiffTargetsAddsAndRemoves(t *testing.T) {
+	active := map[string]Target{
+		"keep":   {Name: "keep", Host: "127.0.0.1", Port: 1, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+		"remove": {Name: "remove", Host: "127.0.0.1", Port: 2, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+	}
+	desired := []Target{
+		{Name: "keep", Host: "127.0.0.1", Port: 1, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+		{Name: "new", Host: "127.0.0.1", Port: 3, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+	}
+
+	toAdd, toRemove := diffTargets(active, desired)
+
+	if len(toAdd) != 1 || toAdd[0].Name != "new" {
+		t.Fatalf("expected only 'new' to be added, got %+v", toAdd)
+	}
+	if len(toRemove) != 1 || toRemove[0] != "remove" {
+		t.Fatalf("expected only 'remove' to be removed, got %v", toRemove)
+	}
+}
+
+func TestDiffTargetsRecreatesChangedTarget(t *testing.T) {
+	active := map[string]Target{
+		"a": {Name: "a", Host: "127.0.0.1", Port: 1, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+	}
+	desired := []Target{
+		{Name: "a", Host: "127.0.0.1", Port: 9999, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+	}
+
+	toAdd, toRemove := diffTargets(active, desired)
+
+	if len(toAdd) != 1 || toAdd[0].Port != 9999 {
+		t.Fatalf("expected changed target to be re-added, got %+v", toAdd)
+	}
+	if len(toRemove) != 1 || toRemove[0] != "a" {
+		t.Fatalf("expected changed target's old worker to be removed, got %v", toRemove)
+	}
+}
+
+func TestApplyTargetDiffAgainstFakeRegistry(t *testing.T) {
+	reg := &fakeRegistry{}
+	active := map[string]Target{
+		"stale": {Name: "stale", Host: "127.0.0.1", Port: 1, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+	}
+	desired := []Target{
+		{Name: "fresh", Host: "127.0.0.1", Port: 2, QueueSize: 32, OverflowPolicy: "drop_newest"},
+	}
+
+	next := applyTargetDiff(reg, active, desired, fakeResolve)
+
+	if len(reg.removed) != 1 || reg.removed[0] != "stale" {
+		t.Fatalf("expected stale target removed from registry, got %v", reg.removed)
+	}
+	if len(reg.added) != 1 || reg.added[0].Name != "fresh" {
+		t.Fatalf("expected fresh target added to registry, got %+v", reg.added)
+	}
+	if _, ok := next["stale"]; ok {
+		t.Fatalf("expected stale target dropped from active set")
+	}
+	if _, ok := next["fresh"]; !ok {
+		t.Fatalf("expected fresh target present in active set")
+	}
+}
+
+func TestApplyTargetDiffSkipsFailedAdd(t *testing.T) {
+	reg := &fakeRegistry{failAdd: map[string]bool{"bad": true}}
+	next := applyTargetDiff(reg, map[string]Target{}, []Target{
+		{Name: "bad", Host: "127.0.0.1", Port: 1, QueueSize: 64, OverflowPolicy: "drop_oldest"},
+	}, fakeResolve)
+
+	if _, ok := next["bad"]; ok {
+		t.Fatalf("expected failed add to be left out of the active set")
+	}
+}
+
+func TestApplyTargetDiffLeavesUnchangedTargetsAlone(t *testing.T) {
+	reg := &fakeRegistry{}
+	target := Target{Name: "steady", Host: "127.0.0.1", Port: 1, QueueSize: 64, OverflowPolicy: "drop_oldest"}
+	active := map[string]Target{"steady": target}
+
+	next := applyTargetDiff(reg, active, []Target{target}, fakeResolve)
+
+	if len(reg.added) != 0 || len(reg.removed) != 0 {
+		t.Fatalf("expected no registry calls for an unchanged target, got added=%+v removed=%v", reg.added, reg.removed)
+	}
+	if _, ok := next["steady"]; !ok {
+		t.Fatalf("expected unchanged target to remain active")
+	}
+}